@@ -0,0 +1,303 @@
+// Copyright (c) 2026, Janoš Guljaš <janos@resenje.org>
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package boltutils
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// ManifestSuffix is appended to the backup file name to get the name of
+// its manifest file.
+const ManifestSuffix = ".manifest"
+
+// ErrManifestMismatch is returned by Restore if the backup file does not
+// match its manifest.
+var ErrManifestMismatch = errors.New("backup does not match manifest")
+
+// Manifest describes a backup file written by Backup.
+type Manifest struct {
+	// Time is when the backup is made.
+	Time time.Time `json:"time"`
+	// TxID is the ID of the transaction in which the backup is made.
+	TxID int `json:"txId"`
+	// PageSize is the database page size in bytes.
+	PageSize int `json:"pageSize"`
+	// PageCount is the number of pages in the database file.
+	PageCount int64 `json:"pageCount"`
+	// Size is the length of the uncompressed database file in bytes.
+	Size int64 `json:"size"`
+	// SHA256 is the hex encoded SHA-256 checksum of the uncompressed
+	// database file.
+	SHA256 string `json:"sha256"`
+	// Compressed is true if the backup file is compressed with gzip.
+	Compressed bool `json:"compressed,omitempty"`
+	// Paths are string representations of backed up buckets, if not the
+	// whole database is backed up.
+	Paths []string `json:"paths,omitempty"`
+}
+
+// BackupOptions holds optional parameters for Backup.
+type BackupOptions struct {
+	// Compress writes the backup file compressed with gzip.
+	Compress bool
+	// Paths reference buckets that are backed up with all their keys
+	// and nested buckets, together with their parent buckets, but
+	// without other keys in them. Expired keys are not backed up and
+	// backed up keys keep their expiration times, but versions and
+	// other data in the meta bucket are not backed up. If no paths are
+	// provided, the whole database is backed up.
+	Paths []Path
+}
+
+// Backup writes a consistent snapshot of the database to the dst file
+// and its Manifest to the file with ManifestSuffix appended to the dst.
+// Both files are replaced only after they are completely written. The
+// database remains available for reads and writes during the backup.
+func Backup(db *bolt.DB, dst string, opts *BackupOptions) (m *Manifest, err error) {
+	if opts == nil {
+		opts = new(BackupOptions)
+	}
+	for i, p := range opts.Paths {
+		if length := len(p.Buckets); length < 1 {
+			return nil, fmt.Errorf("insufficient number of buckets %d < 1", length)
+		}
+		for _, o := range opts.Paths[:i] {
+			if p.HasPrefix(o) || o.HasPrefix(p) {
+				return nil, fmt.Errorf("backup paths %s and %s overlap", o, p)
+			}
+		}
+	}
+
+	m = &Manifest{
+		Time:       time.Now().UTC(),
+		Compressed: opts.Compress,
+	}
+	for _, p := range opts.Paths {
+		m.Paths = append(m.Paths, p.Bucket().String())
+	}
+
+	err = writeFileAtomic(dst, func(f *os.File) (err error) {
+		var w io.Writer = f
+		var gz *gzip.Writer
+		if opts.Compress {
+			gz = gzip.NewWriter(f)
+			w = gz
+		}
+		h := sha256.New()
+		if len(opts.Paths) == 0 {
+			err = db.View(func(tx *bolt.Tx) error {
+				return writeBackup(tx, io.MultiWriter(w, h), m)
+			})
+		} else {
+			err = backupPaths(db, filepath.Dir(dst), opts.Paths, io.MultiWriter(w, h), m)
+		}
+		if err != nil {
+			return err
+		}
+		m.SHA256 = hex.EncodeToString(h.Sum(nil))
+		if gz != nil {
+			return gz.Close()
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFileAtomic(dst+ManifestSuffix, func(f *os.File) error {
+		_, err := f.Write(append(data, '\n'))
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// writeBackup writes the database file as seen by the transaction and
+// sets its properties in the manifest.
+func writeBackup(tx *bolt.Tx, w io.Writer, m *Manifest) (err error) {
+	n, err := tx.WriteTo(w)
+	if err != nil {
+		return fmt.Errorf("write database: %w", err)
+	}
+	m.TxID = tx.ID()
+	m.PageSize = tx.DB().Info().PageSize
+	m.Size = n
+	m.PageCount = n / int64(m.PageSize)
+	return nil
+}
+
+// backupPaths copies buckets referenced by paths into a temporary
+// database in the dir directory and writes that database.
+func backupPaths(db *bolt.DB, dir string, paths []Path, w io.Writer, m *Manifest) (err error) {
+	f, err := os.CreateTemp(dir, ".boltutils-backup-*")
+	if err != nil {
+		return err
+	}
+	name := f.Name()
+	defer os.Remove(name)
+	if err := f.Close(); err != nil {
+		return err
+	}
+	tmp, err := bolt.Open(name, 0o600, &bolt.Options{NoSync: true})
+	if err != nil {
+		return err
+	}
+	defer tmp.Close()
+
+	var txID int
+	now := time.Now()
+	if err := db.View(func(src *bolt.Tx) error {
+		txID = src.ID()
+		return tmp.Update(func(dst *bolt.Tx) error {
+			for _, p := range paths {
+				b := PathBucket(src, p)
+				if b == nil {
+					return &NotFoundError{Key: path(p.Buckets...), Segments: p.Buckets, Op: OpExport, Depth: bucketDepth(src, p.Buckets)}
+				}
+				d, err := createNewBucket(dst, p.Bucket())
+				if err != nil {
+					return err
+				}
+				if err := copyBucket(b, d); err != nil {
+					return fmt.Errorf("bucket %s copy: %w", path(p.Buckets...), err)
+				}
+				if err := copyBucketExpiry(src, dst, p.Bucket(), p.Bucket(), now); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}); err != nil {
+		return err
+	}
+	if err := tmp.View(func(tx *bolt.Tx) error {
+		return writeBackup(tx, w, m)
+	}); err != nil {
+		return err
+	}
+	m.TxID = txID
+	return nil
+}
+
+// Restore verifies the backup file written by Backup against its
+// manifest and the consistency of the database in it, and then puts the
+// uncompressed database file in place of the dst file. The dst database
+// must not be open while it is restored.
+func Restore(src, dst string) (m *Manifest, err error) {
+	data, err := os.ReadFile(src + ManifestSuffix)
+	if err != nil {
+		return nil, err
+	}
+	m = new(Manifest)
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("manifest: %w", err)
+	}
+
+	f, err := os.Open(src)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if m.Compressed {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	err = writeFileAtomic(dst, func(f *os.File) (err error) {
+		h := sha256.New()
+		n, err := io.Copy(io.MultiWriter(f, h), r)
+		if err != nil {
+			return err
+		}
+		if err := checkManifest(m, n, h); err != nil {
+			return err
+		}
+		return checkDatabase(f.Name())
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func checkManifest(m *Manifest, size int64, h hash.Hash) (err error) {
+	if size != m.Size {
+		return fmt.Errorf("size %d, manifest %d: %w", size, m.Size, ErrManifestMismatch)
+	}
+	if m.PageSize <= 0 || size != m.PageCount*int64(m.PageSize) {
+		return fmt.Errorf("size %d, manifest %d pages of %d bytes: %w", size, m.PageCount, m.PageSize, ErrManifestMismatch)
+	}
+	if sum := hex.EncodeToString(h.Sum(nil)); sum != m.SHA256 {
+		return fmt.Errorf("sha256 %s, manifest %s: %w", sum, m.SHA256, ErrManifestMismatch)
+	}
+	return nil
+}
+
+// checkDatabase opens the database file and checks its consistency.
+func checkDatabase(filename string) (err error) {
+	db, err := bolt.Open(filename, 0o600, &bolt.Options{ReadOnly: true, Timeout: time.Second})
+	if err != nil {
+		return fmt.Errorf("open database: %w", err)
+	}
+	defer db.Close()
+	return db.View(func(tx *bolt.Tx) (err error) {
+		// All errors are received to let the check finish.
+		for e := range tx.Check() {
+			if err == nil {
+				err = fmt.Errorf("check database: %w", e)
+			}
+		}
+		return err
+	})
+}
+
+// writeFileAtomic writes a temporary file in the same directory as the
+// filename with the write function and renames it to the filename only
+// if all data is written and synced.
+func writeFileAtomic(filename string, write func(f *os.File) error) (err error) {
+	f, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+	if err := write(f); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filename)
+}
@@ -0,0 +1,179 @@
+// Copyright (c) 2026, Janoš Guljaš <janos@resenje.org>
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package boltutils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestBackup(t *testing.T) {
+	db := newTestDB(t)
+	defer db.Destroy()
+
+	if err := db.DB.Update(func(tx *bolt.Tx) error {
+		for _, elements := range [][]string{
+			{"a", "k1", "v1"},
+			{"a", "b", "k2", "v2"},
+			{"a", "b", "c", "k3", "v3"},
+			{"d", "k4", "v4"},
+			{"e", "k5", "v5"},
+		} {
+			e := make([][]byte, 0, len(elements))
+			for _, s := range elements {
+				e = append(e, []byte(s))
+			}
+			if _, err := DeepPut(tx, true, e...); err != nil {
+				return err
+			}
+		}
+		if _, err := PathPutWithExpiry(tx, true, NewKeyPath([]byte("k6"), []byte("a")), []byte("v6"), time.Now().Add(time.Hour)); err != nil {
+			return err
+		}
+		if _, err := PathPutWithExpiry(tx, true, NewKeyPath([]byte("k7"), []byte("a"), []byte("b")), []byte("v7"), time.Now().Add(time.Hour)); err != nil {
+			return err
+		}
+		_, err := PathPutWithExpiry(tx, true, NewKeyPath([]byte("k8"), []byte("a"), []byte("b")), []byte("v8"), time.Now().Add(-time.Hour))
+		return err
+	}); err != nil {
+		t.Fatalf("bolt db update transaction %s", err)
+	}
+
+	dir := t.TempDir()
+
+	restore := func(t *testing.T, src string) testDB {
+		t.Helper()
+		dst := filepath.Join(dir, "restored.db")
+		if _, err := Restore(src, dst); err != nil {
+			t.Fatalf("restore: %s", err)
+		}
+		r, err := bolt.Open(dst, 0o600, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return testDB{r}
+	}
+
+	for _, compress := range []bool{false, true} {
+		t.Run(fmt.Sprintf("compress %v", compress), func(t *testing.T) {
+			src := filepath.Join(dir, "backup.db")
+			m, err := Backup(db.DB, src, &BackupOptions{Compress: compress})
+			if err != nil {
+				t.Fatalf("backup: %s", err)
+			}
+			if m.PageSize != db.Info().PageSize || m.Size != m.PageCount*int64(m.PageSize) || len(m.SHA256) != 64 || m.Compressed != compress || m.TxID == 0 || m.Time.IsZero() {
+				t.Errorf("invalid manifest %+v", m)
+			}
+			if _, err := os.Stat(src + ManifestSuffix); err != nil {
+				t.Errorf("manifest: %s", err)
+			}
+
+			r := restore(t, src)
+			defer r.Destroy()
+			for _, name := range []string{"a", "d", "e"} {
+				p := NewPath([]byte(name))
+				if got, want := dump(t, r, p), dump(t, db, p); fmt.Sprint(got) != fmt.Sprint(want) {
+					t.Errorf("%s: got %q, want %q", name, got, want)
+				}
+			}
+			if err := r.DB.View(func(tx *bolt.Tx) error {
+				if _, ok := PathExpiry(tx, NewKeyPath([]byte("k6"), []byte("a"))); !ok {
+					t.Error("expiry not restored")
+				}
+				return nil
+			}); err != nil {
+				t.Fatalf("bolt db view transaction %s", err)
+			}
+		})
+	}
+
+	t.Run("Paths", func(t *testing.T) {
+		src := filepath.Join(dir, "paths.db")
+		m, err := Backup(db.DB, src, &BackupOptions{
+			Compress: true,
+			Paths:    []Path{NewPath([]byte("a"), []byte("b")), NewPath([]byte("d"))},
+		})
+		if err != nil {
+			t.Fatalf("backup: %s", err)
+		}
+		if fmt.Sprint(m.Paths) != "[a/b d]" {
+			t.Errorf("got paths %q", m.Paths)
+		}
+		r := restore(t, src)
+		defer r.Destroy()
+		want := []string{"a/b/", "a/b/c/", "a/b/c/k3=v3", "a/b/k2=v2", "a/b/k7=v7", "d/k4=v4"}
+		var got []string
+		for _, name := range []string{"a", "d", "e"} {
+			got = append(got, dump(t, r, NewPath([]byte(name)))...)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("got %q, want %q", got, want)
+		}
+		if err := r.DB.View(func(tx *bolt.Tx) error {
+			if _, ok := PathExpiry(tx, NewKeyPath([]byte("k7"), []byte("a"), []byte("b"))); !ok {
+				t.Error("expiry not restored")
+			}
+			if v := PathBucket(tx, NewPath([]byte("a"), []byte("b"))).Get([]byte("k8")); v != nil {
+				t.Errorf("got expired key value %q", v)
+			}
+			// Only the expiration time of the backed up key is restored.
+			if n := metaBucket(tx, ttlKeysBucketName).Stats().KeyN; n != 1 {
+				t.Errorf("got %d expiring keys, want %d", n, 1)
+			}
+			return nil
+		}); err != nil {
+			t.Fatalf("bolt db view transaction %s", err)
+		}
+
+		if _, err := Backup(db.DB, src, &BackupOptions{Paths: []Path{NewPath([]byte("a")), NewPath([]byte("a"), []byte("b"))}}); err == nil {
+			t.Error("no error for overlapping paths")
+		}
+		if _, err := Backup(db.DB, src, &BackupOptions{Paths: []Path{NewPath([]byte("a"), []byte("x"))}}); !IsNotFoundError(err) {
+			t.Errorf("invalid error: %v", err)
+		}
+	})
+
+	t.Run("Mismatch", func(t *testing.T) {
+		src := filepath.Join(dir, "mismatch.db")
+		if _, err := Backup(db.DB, src, nil); err != nil {
+			t.Fatalf("backup: %s", err)
+		}
+		data, err := os.ReadFile(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data[len(data)/2] ^= 0xff
+		if err := os.WriteFile(src, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		dst := filepath.Join(dir, "existing.db")
+		if err := os.WriteFile(dst, []byte("existing"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Restore(src, dst); !errors.Is(err, ErrManifestMismatch) {
+			t.Errorf("got error %v, want %v", err, ErrManifestMismatch)
+		}
+		if data, err := os.ReadFile(dst); err != nil || string(data) != "existing" {
+			t.Errorf("destination file changed: %q, error %v", data, err)
+		}
+		if _, err := Restore(filepath.Join(dir, "missing.db"), dst); !os.IsNotExist(err) {
+			t.Errorf("invalid error: %v", err)
+		}
+		matches, err := filepath.Glob(filepath.Join(dir, ".*"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(matches) > 0 {
+			t.Errorf("temporary files not removed: %q", matches)
+		}
+	})
+}
@@ -0,0 +1,213 @@
+// Copyright (c) 2026, Janoš Guljaš <janos@resenje.org>
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package boltutils
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+type batchOpKind int
+
+const (
+	batchPut batchOpKind = iota
+	batchDelete
+	batchDeleteBucket
+)
+
+type batchOp struct {
+	kind  batchOpKind
+	p     Path
+	value []byte
+	flag  bool
+}
+
+// BatchResult is the result of a single Batch operation.
+type BatchResult struct {
+	// New is true if the put operation saved the key for the first time.
+	New bool
+	// Err is ExistsError or NotFoundError if the operation was not
+	// applied for the reasons described in PathPut, PathDelete and
+	// PathDeleteBucket.
+	Err error
+}
+
+// Batch collects put and delete operations on keys and buckets
+// referenced by different paths, to apply them with a single lookup of
+// every bucket. Operations between bucket deletions are grouped by their
+// bucket paths, preserving the order of operations on the same bucket.
+// Bucket deletions are applied in the same order relative to other
+// operations as they are added.
+type Batch struct {
+	ops []batchOp
+}
+
+// NewBatch returns a new empty Batch.
+func NewBatch() (b *Batch) {
+	return new(Batch)
+}
+
+// Put adds an operation that saves the value in the same way as PathPut.
+func (b *Batch) Put(overwrite bool, p Path, value []byte) {
+	b.ops = append(b.ops, batchOp{kind: batchPut, p: p, value: value, flag: overwrite})
+}
+
+// Delete adds an operation that deletes the key in the same way as
+// PathDelete.
+func (b *Batch) Delete(ensure bool, p Path) {
+	b.ops = append(b.ops, batchOp{kind: batchDelete, p: p, flag: ensure})
+}
+
+// DeleteBucket adds an operation that deletes the bucket in the same way
+// as PathDeleteBucket.
+func (b *Batch) DeleteBucket(ensure bool, p Path) {
+	b.ops = append(b.ops, batchOp{kind: batchDeleteBucket, p: p, flag: ensure})
+}
+
+// Len returns the number of operations in the batch.
+func (b *Batch) Len() int {
+	return len(b.ops)
+}
+
+// Apply applies all operations in the provided transaction. Results are
+// returned in the order in which operations were added. Errors other
+// than ExistsError and NotFoundError abort the batch and should cause
+// the transaction to be rolled back.
+func (b *Batch) Apply(tx *bolt.Tx) (results []BatchResult, err error) {
+	results = make([]BatchResult, len(b.ops))
+	if err := b.apply(tx, b.order(), results); err != nil {
+		return results, err
+	}
+	return results, nil
+}
+
+// Commit applies all operations in write transactions on the provided
+// database, with at most maxOps operations in a single transaction. If
+// maxOps is zero or negative, a single transaction is used. If an error
+// is returned, transactions that were committed before it are not
+// rolled back, and results of operations that were not committed are
+// zero values.
+func (b *Batch) Commit(db *bolt.DB, maxOps int) (results []BatchResult, err error) {
+	results = make([]BatchResult, len(b.ops))
+	order := b.order()
+	if maxOps <= 0 {
+		maxOps = len(order)
+	}
+	for len(order) > 0 {
+		n := maxOps
+		if n > len(order) {
+			n = len(order)
+		}
+		if err := db.Update(func(tx *bolt.Tx) error {
+			return b.apply(tx, order[:n], results)
+		}); err != nil {
+			// Operations in the rolled back transaction have no results.
+			for _, i := range order[:n] {
+				results[i] = BatchResult{}
+			}
+			return results, err
+		}
+		order = order[n:]
+	}
+	return results, nil
+}
+
+// order returns indexes of operations in the order in which they are
+// applied. Only operations between bucket deletions are reordered.
+func (b *Batch) order() (order []int) {
+	order = make([]int, 0, len(b.ops))
+	start := 0
+	sortRun := func() {
+		run := order[start:]
+		sort.SliceStable(run, func(i, j int) bool {
+			return compareBuckets(b.ops[run[i]].p, b.ops[run[j]].p) < 0
+		})
+	}
+	for i, op := range b.ops {
+		if op.kind == batchDeleteBucket {
+			sortRun()
+			order = append(order, i)
+			start = len(order)
+			continue
+		}
+		order = append(order, i)
+	}
+	sortRun()
+	return order
+}
+
+// compareBuckets compares bucket names of two paths, element by element.
+func compareBuckets(a, b Path) int {
+	for i := 0; i < len(a.Buckets) && i < len(b.Buckets); i++ {
+		if c := bytes.Compare(a.Buckets[i], b.Buckets[i]); c != 0 {
+			return c
+		}
+	}
+	return len(a.Buckets) - len(b.Buckets)
+}
+
+// apply applies operations with provided indexes in order, saving their
+// results.
+func (b *Batch) apply(tx *bolt.Tx, order []int, results []BatchResult) (err error) {
+	var (
+		bucket  *bolt.Bucket
+		current Path
+		opened  bool
+	)
+	for _, i := range order {
+		op := b.ops[i]
+		if op.kind == batchDeleteBucket {
+			err := PathDeleteBucket(tx, op.flag, op.p)
+			if err != nil && !IsNotFoundError(err) {
+				return fmt.Errorf("batch operation %d: %w", i, err)
+			}
+			results[i].Err = err
+			// The current bucket may be deleted.
+			opened = false
+			continue
+		}
+		if length := len(op.p.Buckets); length < 1 {
+			return fmt.Errorf("batch operation %d: insufficient number of buckets %d < 1", i, length)
+		}
+		if !op.p.HasKey() {
+			return fmt.Errorf("batch operation %d: path %s has no key", i, op.p)
+		}
+		if !opened || !current.Equal(op.p.Bucket()) {
+			current = op.p.Bucket()
+			bucket = PathBucket(tx, current)
+			opened = true
+		}
+		switch op.kind {
+		case batchPut:
+			if bucket == nil {
+				bucket, err = PathCreateBucketIfNotExists(tx, current)
+				if err != nil {
+					return fmt.Errorf("batch operation %d: %w", i, err)
+				}
+			}
+			results[i].New, err = putKey(tx, bucket, op.flag, op.p, op.value)
+			if err != nil && !IsExistsError(err) {
+				return fmt.Errorf("batch operation %d: %w", i, err)
+			}
+		case batchDelete:
+			if bucket == nil {
+				// PathDelete returns the same error as for a single
+				// operation, referencing the first missing bucket.
+				err = PathDelete(tx, op.flag, op.p)
+			} else {
+				err = deleteKey(tx, bucket, op.flag, op.p)
+			}
+			if err != nil && !IsNotFoundError(err) {
+				return fmt.Errorf("batch operation %d: %w", i, err)
+			}
+		}
+		results[i].Err = err
+	}
+	return nil
+}
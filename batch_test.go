@@ -0,0 +1,211 @@
+// Copyright (c) 2026, Janoš Guljaš <janos@resenje.org>
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package boltutils
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestBatch(t *testing.T) {
+	db := newTestDB(t)
+	defer db.Destroy()
+
+	a := NewPath([]byte("a"))
+	ab := a.Child([]byte("b"))
+	c := NewPath([]byte("c"))
+
+	if err := db.DB.Update(func(tx *bolt.Tx) error {
+		if _, err := PathPut(tx, false, a.WithKey([]byte("k1")), []byte("old")); err != nil {
+			return err
+		}
+		_, err := PathPut(tx, false, c.WithKey([]byte("k1")), []byte("v1"))
+		return err
+	}); err != nil {
+		t.Fatalf("bolt db update transaction %s", err)
+	}
+
+	b := NewBatch()
+	b.Put(false, ab.WithKey([]byte("k2")), []byte("v2"))
+	b.Put(false, a.WithKey([]byte("k1")), []byte("v1"))
+	b.Put(true, a.WithKey([]byte("k3")), []byte("v3"))
+	b.DeleteBucket(true, c)
+	b.Delete(true, ab.WithKey([]byte("missing")))
+	b.Put(true, c.WithKey([]byte("k2")), []byte("v2"))
+	b.Put(true, a.WithKey([]byte("k3")), []byte("v4"))
+	b.Delete(false, NewKeyPath([]byte("k"), []byte("x"), []byte("y")))
+	b.Delete(true, NewKeyPath([]byte("k"), []byte("x"), []byte("y")))
+	b.DeleteBucket(true, NewPath([]byte("x")))
+	b.Delete(true, a.WithKey([]byte("k1")))
+
+	if l := b.Len(); l != 11 {
+		t.Errorf("got length %d, want %d", l, 11)
+	}
+
+	var results []BatchResult
+	if err := db.DB.Update(func(tx *bolt.Tx) (err error) {
+		results, err = b.Apply(tx)
+		return err
+	}); err != nil {
+		t.Fatalf("apply: %s", err)
+	}
+
+	want := []BatchResult{
+		{New: true},
+		{Err: NewExistsError("a, k1")},
+		{New: true},
+		{},
+		{Err: NewNotFoundError("a, b")},
+		{New: true},
+		{},
+		{},
+		{Err: NewNotFoundError("x")},
+		{Err: NewNotFoundError("x")},
+		{},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("got %d results, want %d", len(results), len(want))
+	}
+	for i, r := range results {
+		if r.New != want[i].New || fmt.Sprint(r.Err) != fmt.Sprint(want[i].Err) {
+			t.Errorf("result %d: got %+v, want %+v", i, r, want[i])
+		}
+	}
+
+	wantDump := []string{
+		"a/",
+		"a/b/",
+		"a/b/k2=v2",
+		"a/k3=v4",
+		// Put after the deletion of its bucket is applied to a new bucket.
+		"c/",
+		"c/k2=v2",
+	}
+	if got := dump(t, db, Path{}); !reflect.DeepEqual(got, wantDump) {
+		t.Errorf("got %q, want %q", got, wantDump)
+	}
+
+	t.Run("Invalid", func(t *testing.T) {
+		b := NewBatch()
+		b.Put(true, a.WithKey([]byte("k4")), []byte("v4"))
+		b.Put(true, a, []byte("v"))
+		if err := db.DB.Update(func(tx *bolt.Tx) error {
+			_, err := b.Apply(tx)
+			return err
+		}); err == nil {
+			t.Error("expected error")
+		}
+		if got := dump(t, db, Path{}); !reflect.DeepEqual(got, wantDump) {
+			t.Errorf("got %q, want %q", got, wantDump)
+		}
+	})
+}
+
+func TestBatchCommit(t *testing.T) {
+	db := newTestDB(t)
+	defer db.Destroy()
+
+	b := NewBatch()
+	for i := 0; i < 10; i++ {
+		b.Put(false, NewKeyPath([]byte(fmt.Sprintf("k%d", i)), []byte("a"), []byte(fmt.Sprint(i%3))), []byte("v"))
+	}
+	b.Put(false, NewKeyPath([]byte("k0"), []byte("a"), []byte("0")), []byte("v"))
+
+	results, err := b.Commit(db.DB, 3)
+	if err != nil {
+		t.Fatalf("commit: %s", err)
+	}
+	for i, r := range results[:10] {
+		if !r.New || r.Err != nil {
+			t.Errorf("result %d: got %+v", i, r)
+		}
+	}
+	if r := results[10]; !IsExistsError(r.Err) {
+		t.Errorf("got %+v", r)
+	}
+	if got := dump(t, db, NewPath([]byte("a"))); len(got) != 13 {
+		t.Errorf("got %q", got)
+	}
+
+	// Operations in transactions committed before an error are saved.
+	b = NewBatch()
+	b.Put(false, NewKeyPath([]byte("k"), []byte("b")), []byte("v"))
+	b.Put(false, NewKeyPath([]byte("k"), []byte("c")), []byte("v"))
+	b.Put(false, NewKeyPath([]byte("k"), []byte("e")), []byte("v"))
+	b.Put(false, NewPath([]byte("z")), []byte("v"))
+	results, err = b.Commit(db.DB, 2)
+	if err == nil {
+		t.Error("expected error")
+	}
+	// Results of operations in the rolled back transaction are not set.
+	for i, want := range []BatchResult{{New: true}, {New: true}, {}, {}} {
+		if r := results[i]; r != want {
+			t.Errorf("result %d: got %+v, want %+v", i, r, want)
+		}
+	}
+	want := []string{"b/", "b/k=v", "c/", "c/k=v"}
+	var got []string
+	for _, s := range dump(t, db, Path{}) {
+		if s[0] != 'a' {
+			got = append(got, s)
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func benchmarkPaths(n int) (paths []Path) {
+	paths = make([]Path, 0, n)
+	for i := 0; i < n; i++ {
+		paths = append(paths, NewKeyPath(
+			[]byte(fmt.Sprintf("key%d", i)),
+			[]byte("level1"), []byte("level2"), []byte(fmt.Sprintf("level3-%d", i%10)),
+		))
+	}
+	return paths
+}
+
+func BenchmarkBatch(b *testing.B) {
+	paths := benchmarkPaths(1000)
+	value := []byte("value")
+
+	b.Run("DeepPut", func(b *testing.B) {
+		db := newTestDB(b)
+		defer db.Destroy()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if err := db.DB.Update(func(tx *bolt.Tx) error {
+				for _, p := range paths {
+					if _, err := DeepPut(tx, true, append(p.Elements(), value)...); err != nil {
+						return err
+					}
+				}
+				return nil
+			}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("Batch", func(b *testing.B) {
+		db := newTestDB(b)
+		defer db.Destroy()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			batch := NewBatch()
+			for _, p := range paths {
+				batch.Put(true, p, value)
+			}
+			if _, err := batch.Commit(db.DB, 0); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,160 @@
+// Copyright (c) 2026, Janoš Guljaš <janos@resenje.org>
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package boltutils
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// BucketCache resolves buckets referenced by paths in a single
+// transaction and remembers them, so that repeated lookups of keys in
+// the same nested buckets do not traverse all parent buckets again.
+// Buckets must be deleted only with the BucketCache methods while it is
+// used, or Reset must be called after they are deleted by other means.
+// BucketCache is not safe for concurrent use, as is the transaction.
+type BucketCache struct {
+	tx      *bolt.Tx
+	buckets map[string]*bolt.Bucket
+	key     []byte
+}
+
+// NewBucketCache returns a new BucketCache for the transaction.
+func NewBucketCache(tx *bolt.Tx) (c *BucketCache) {
+	return &BucketCache{
+		tx:      tx,
+		buckets: make(map[string]*bolt.Bucket),
+	}
+}
+
+// Tx returns the transaction of the cache.
+func (c *BucketCache) Tx() *bolt.Tx {
+	return c.tx
+}
+
+// Reset removes all cached buckets.
+func (c *BucketCache) Reset() {
+	c.buckets = make(map[string]*bolt.Bucket)
+}
+
+// Bucket returns the bucket referenced by the path in the same way as
+// PathBucket.
+func (c *BucketCache) Bucket(p Path) (bucket *bolt.Bucket) {
+	length := len(p.Buckets)
+	if length < 1 {
+		return nil
+	}
+	c.key = cacheKey(c.key[:0], p.Buckets)
+	if b, ok := c.buckets[string(c.key)]; ok {
+		return b
+	}
+	c.key = c.key[:0]
+	for i, name := range p.Buckets {
+		c.key = appendCacheKey(c.key, name)
+		if b, ok := c.buckets[string(c.key)]; ok {
+			bucket = b
+			continue
+		}
+		if i == 0 {
+			bucket = c.tx.Bucket(name)
+		} else {
+			bucket = bucket.Bucket(name)
+		}
+		if bucket == nil {
+			return nil
+		}
+		c.buckets[string(c.key)] = bucket
+	}
+	return bucket
+}
+
+// Get retrieves the value of the key referenced by the path in the same
+// way as PathGet.
+func (c *BucketCache) Get(p Path) (data []byte) {
+	if !p.HasKey() {
+		return nil
+	}
+	bucket := c.Bucket(p)
+	if bucket == nil {
+		return nil
+	}
+	data = bucket.Get(p.Key)
+	if data != nil && isExpired(c.tx, p, time.Now()) {
+		return nil
+	}
+	return data
+}
+
+// CreateBucketIfNotExists creates nested buckets referenced by the path
+// in the same way as PathCreateBucketIfNotExists.
+func (c *BucketCache) CreateBucketIfNotExists(p Path) (bucket *bolt.Bucket, err error) {
+	if bucket = c.Bucket(p); bucket != nil {
+		return bucket, nil
+	}
+	bucket, err = PathCreateBucketIfNotExists(c.tx, p)
+	if err != nil {
+		return nil, err
+	}
+	c.buckets[string(cacheKey(nil, p.Buckets))] = bucket
+	return bucket, nil
+}
+
+// Put saves the value under the key referenced by the path in the same
+// way as PathPut.
+func (c *BucketCache) Put(overwrite bool, p Path, value []byte) (new bool, err error) {
+	if !p.HasKey() {
+		return false, fmt.Errorf("path %s has no key", p)
+	}
+	bucket, err := c.CreateBucketIfNotExists(p)
+	if err != nil {
+		return false, err
+	}
+	return putKey(c.tx, bucket, overwrite, p, value)
+}
+
+// Delete deletes the key referenced by the path in the same way as
+// PathDelete.
+func (c *BucketCache) Delete(ensure bool, p Path) (err error) {
+	if !p.HasKey() {
+		return fmt.Errorf("path %s has no key", p)
+	}
+	bucket := c.Bucket(p)
+	if bucket == nil {
+		return PathDelete(c.tx, ensure, p)
+	}
+	return deleteKey(c.tx, bucket, ensure, p)
+}
+
+// DeleteBucket deletes the bucket referenced by the path in the same way
+// as PathDeleteBucket, and removes it and all its nested buckets from
+// the cache.
+func (c *BucketCache) DeleteBucket(ensure bool, p Path) (err error) {
+	if len(p.Buckets) > 0 {
+		prefix := string(cacheKey(nil, p.Buckets))
+		for k := range c.buckets {
+			if strings.HasPrefix(k, prefix) {
+				delete(c.buckets, k)
+			}
+		}
+	}
+	return PathDeleteBucket(c.tx, ensure, p)
+}
+
+// cacheKey appends the cache key of the bucket with provided names. Keys
+// of parent buckets are prefixes of keys of their nested buckets.
+func cacheKey(dst []byte, names [][]byte) []byte {
+	for _, name := range names {
+		dst = appendCacheKey(dst, name)
+	}
+	return dst
+}
+
+func appendCacheKey(dst, name []byte) []byte {
+	return append(appendUvarint(dst, uint64(len(name))), name...)
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2026, Janoš Guljaš <janos@resenje.org>
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package boltutils
+
+import (
+	"fmt"
+	"testing"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestBucketCache(t *testing.T) {
+	db := newTestDB(t)
+	defer db.Destroy()
+
+	ab := NewPath([]byte("a"), []byte("b"))
+	abc := ab.Child([]byte("c"))
+
+	if err := db.DB.Update(func(tx *bolt.Tx) error {
+		c := NewBucketCache(tx)
+		if c.Tx() != tx {
+			t.Error("invalid transaction")
+		}
+		if b := c.Bucket(abc); b != nil {
+			t.Error("got bucket before it is created")
+		}
+		if v := c.Get(abc.WithKey([]byte("k"))); v != nil {
+			t.Errorf("got value %q", v)
+		}
+		if new, err := c.Put(false, abc.WithKey([]byte("k")), []byte("v1")); err != nil || !new {
+			t.Errorf("put: new %v, error %v", new, err)
+		}
+		if _, err := c.Put(false, abc.WithKey([]byte("k")), []byte("v2")); !IsExistsError(err) {
+			t.Errorf("put: invalid error: %v", err)
+		}
+		if v := string(c.Get(abc.WithKey([]byte("k")))); v != "v1" {
+			t.Errorf("got value %q, want %q", v, "v1")
+		}
+		if b := c.Bucket(abc); b != PathBucket(tx, abc) {
+			t.Error("got different bucket")
+		}
+		if _, err := c.Put(true, ab.WithKey([]byte("k")), []byte("v3")); err != nil {
+			return err
+		}
+
+		if err := c.DeleteBucket(true, ab); err != nil {
+			return err
+		}
+		for _, p := range []Path{ab, abc} {
+			if b := c.Bucket(p); b != nil {
+				t.Errorf("%s: got bucket after it is deleted", p)
+			}
+		}
+		if b := c.Bucket(NewPath([]byte("a"))); b == nil {
+			t.Error("parent bucket not found")
+		}
+		if err := c.Delete(true, abc.WithKey([]byte("k"))); !IsNotFoundError(err) {
+			t.Errorf("delete: invalid error: %v", err)
+		}
+
+		// Buckets created again are resolved.
+		if _, err := c.Put(false, abc.WithKey([]byte("k")), []byte("v4")); err != nil {
+			return err
+		}
+		if v := string(c.Get(abc.WithKey([]byte("k")))); v != "v4" {
+			t.Errorf("got value %q, want %q", v, "v4")
+		}
+		if v := string(PathGet(tx, abc.WithKey([]byte("k")))); v != "v4" {
+			t.Errorf("got value %q, want %q", v, "v4")
+		}
+		if err := c.Delete(true, abc.WithKey([]byte("k"))); err != nil {
+			return err
+		}
+		if v := c.Get(abc.WithKey([]byte("k"))); v != nil {
+			t.Errorf("got value %q", v)
+		}
+
+		// Buckets deleted without the cache are resolved after Reset.
+		if err := PathDeleteBucket(tx, true, ab); err != nil {
+			return err
+		}
+		c.Reset()
+		if b := c.Bucket(abc); b != nil {
+			t.Error("got bucket after it is deleted")
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("bolt db update transaction %s", err)
+	}
+}
+
+func BenchmarkBucketCache(b *testing.B) {
+	db := newTestDB(b)
+	defer db.Destroy()
+
+	buckets := NewPath([]byte("level1"), []byte("level2"), []byte("level3"), []byte("level4"))
+	keys := make([][]byte, 100)
+	if err := db.DB.Update(func(tx *bolt.Tx) error {
+		for i := range keys {
+			keys[i] = []byte(fmt.Sprintf("key%d", i))
+			if _, err := PathPut(tx, true, buckets.WithKey(keys[i]), []byte("value")); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("DeepGet", func(b *testing.B) {
+		if err := db.DB.View(func(tx *bolt.Tx) error {
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if v := DeepGet(tx, append(buckets.Buckets, keys[i%len(keys)])...); v == nil {
+					b.Fatal("value not found")
+				}
+			}
+			return nil
+		}); err != nil {
+			b.Fatal(err)
+		}
+	})
+
+	b.Run("BucketCache", func(b *testing.B) {
+		if err := db.DB.View(func(tx *bolt.Tx) error {
+			c := NewBucketCache(tx)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if v := c.Get(buckets.WithKey(keys[i%len(keys)])); v == nil {
+					b.Fatal("value not found")
+				}
+			}
+			return nil
+		}); err != nil {
+			b.Fatal(err)
+		}
+	})
+}
@@ -0,0 +1,486 @@
+// Copyright (c) 2026, Janoš Guljaš <janos@resenje.org>
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Command boltutils inspects and edits Bolt database files using deep
+// paths to keys and nested buckets.
+//
+// Paths are slash-separated bucket names, optionally followed by a key,
+// with slash and backslash characters escaped with a backslash and
+// arbitrary bytes represented as \xHH sequences.
+//
+// Usage:
+//
+//	boltutils <command> [flags] <database> [arguments]
+//
+// Commands:
+//
+//	get <database> <path>            print the value of a key
+//	put <database> <path> [value]    save a value, read from stdin if omitted
+//	delete <database> <path>         delete a key or a bucket with -bucket
+//	ls <database> [path]             list keys and buckets in a bucket
+//	tree <database> [path]           print all nested keys and buckets
+//	export <database> [path]         write a bucket as JSON to stdout
+//	import <database> [path]         read a bucket as JSON from stdin
+//	stats <database> [path]          print bucket statistics
+//	compact <database> <destination> write a compacted copy of a database
+//
+// Databases are opened read-only, unless the command writes to them.
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	"resenje.org/boltutils"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// command is a boltutils subcommand.
+type command struct {
+	usage string
+	// write is true if the command requires the database to be opened
+	// for writing.
+	write bool
+	run   func(c *cmdContext, args []string) error
+}
+
+var commands = map[string]command{
+	"get":     {usage: "get [flags] <database> <path>", run: runGet},
+	"put":     {usage: "put [flags] <database> <path> [value]", write: true, run: runPut},
+	"delete":  {usage: "delete [flags] <database> <path>", write: true, run: runDelete},
+	"ls":      {usage: "ls [flags] <database> [path]", run: runLs},
+	"tree":    {usage: "tree [flags] <database> [path]", run: runTree},
+	"export":  {usage: "export [flags] <database> [path]", run: runExport},
+	"import":  {usage: "import [flags] <database> [path]", write: true, run: runImport},
+	"stats":   {usage: "stats [flags] <database> [path]", run: runStats},
+	"compact": {usage: "compact [flags] <database> <destination>", run: runCompact},
+}
+
+var commandNames = []string{"get", "put", "delete", "ls", "tree", "export", "import", "stats", "compact"}
+
+// cmdContext holds parsed flags and streams of a running command.
+type cmdContext struct {
+	flags  *flag.FlagSet
+	stdin  io.Reader
+	stdout io.Writer
+	db     *bolt.DB
+
+	keys     string
+	hexValue bool
+	ensure   bool
+	bucket   bool
+	noOver   bool
+	ndjson   bool
+	hexEnc   bool
+	conflict string
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) < 1 {
+		printUsage(stderr)
+		return 2
+	}
+	name := args[0]
+	cmd, ok := commands[name]
+	if !ok {
+		if name == "help" || name == "-h" || name == "-help" || name == "--help" {
+			printUsage(stdout)
+			return 0
+		}
+		fmt.Fprintf(stderr, "boltutils: unknown command %q\n", name)
+		printUsage(stderr)
+		return 2
+	}
+
+	c := &cmdContext{
+		flags:  flag.NewFlagSet(name, flag.ContinueOnError),
+		stdin:  stdin,
+		stdout: stdout,
+	}
+	c.flags.SetOutput(stderr)
+	c.flags.Usage = func() {
+		fmt.Fprintf(stderr, "usage: boltutils %s\n", cmd.usage)
+		c.flags.PrintDefaults()
+	}
+	c.flags.StringVar(&c.keys, "keys", "raw", "key form for the last path element and listed keys: raw, hex, natural-reversible or time (keys encoded with Natural can not be decoded and are listed as hex)")
+	switch name {
+	case "get":
+		c.flags.BoolVar(&c.hexValue, "hex", false, "print the value as a hexadecimal string")
+	case "put":
+		c.flags.BoolVar(&c.hexValue, "hex", false, "read the value as a hexadecimal string")
+		c.flags.BoolVar(&c.noOver, "no-overwrite", false, "fail if the key already exists")
+	case "delete":
+		c.flags.BoolVar(&c.bucket, "bucket", false, "delete a bucket instead of a key")
+		c.flags.BoolVar(&c.ensure, "ensure", false, "fail if the key or bucket does not exist")
+	case "export", "import":
+		c.flags.BoolVar(&c.ndjson, "ndjson", false, "use newline-delimited JSON")
+		c.flags.BoolVar(&c.hexEnc, "hex", false, "encode names, keys and values as hexadecimal instead of base64 strings")
+		if name == "import" {
+			c.flags.StringVar(&c.conflict, "conflict", "fail", "existing keys handling: fail, overwrite or merge")
+		}
+	}
+	if err := c.flags.Parse(args[1:]); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+	rest := c.flags.Args()
+	if len(rest) < 1 {
+		c.flags.Usage()
+		return 2
+	}
+
+	if name != "compact" {
+		db, err := openDB(rest[0], !cmd.write)
+		if err != nil {
+			fmt.Fprintf(stderr, "boltutils: %s\n", err)
+			return 1
+		}
+		defer db.Close()
+		c.db = db
+	}
+
+	if err := cmd.run(c, rest); err != nil {
+		if errors.Is(err, errUsage) {
+			c.flags.Usage()
+			return 2
+		}
+		fmt.Fprintf(stderr, "boltutils: %s\n", err)
+		return 1
+	}
+	return 0
+}
+
+var errUsage = errors.New("invalid usage")
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "usage: boltutils <command> [flags] <database> [arguments]")
+	fmt.Fprintln(w, "commands:")
+	for _, name := range commandNames {
+		fmt.Fprintf(w, "  %s\n", commands[name].usage)
+	}
+}
+
+func openDB(filename string, readOnly bool) (db *bolt.DB, err error) {
+	if _, err := os.Stat(filename); err != nil && readOnly {
+		return nil, err
+	}
+	return bolt.Open(filename, 0o600, &bolt.Options{
+		ReadOnly: readOnly,
+		Timeout:  time.Second,
+	})
+}
+
+// parsePath parses a bucket path argument. Leading and trailing
+// delimiters are ignored.
+func parsePath(s string) (p boltutils.Path, err error) {
+	return boltutils.ParsePath(trimPath(s))
+}
+
+// parseKeyPath parses a key path argument, converting the key to the
+// form selected by the keys flag.
+func (c *cmdContext) parseKeyPath(s string) (p boltutils.Path, err error) {
+	p, err = boltutils.ParseKeyPath(trimPath(s))
+	if err != nil {
+		return p, err
+	}
+	if len(p.Buckets) < 1 {
+		return p, fmt.Errorf("path %q has no bucket", s)
+	}
+	p.Key, err = c.encodeKey(p.Key)
+	return p, err
+}
+
+func trimPath(s string) string {
+	s = strings.TrimPrefix(s, "/")
+	if strings.HasSuffix(s, "/") && !strings.HasSuffix(s, `\/`) {
+		s = s[:len(s)-1]
+	}
+	return s
+}
+
+// encodeKey converts the key from the command line to the stored form.
+func (c *cmdContext) encodeKey(key []byte) (k []byte, err error) {
+	switch c.keys {
+	case "raw":
+		return key, nil
+	case "hex":
+		return hex.DecodeString(string(key))
+	case "natural-reversible":
+		return boltutils.NaturalReversibleBytes(key), nil
+	case "time":
+		t, err := time.Parse(time.RFC3339Nano, string(key))
+		if err != nil {
+			return nil, err
+		}
+		k = make([]byte, boltutils.TimeBytesLen)
+		return k, boltutils.PutTimeBytes(k, t)
+	}
+	return nil, fmt.Errorf("unknown keys form %q", c.keys)
+}
+
+// formatKey converts the stored key to the form selected by the keys
+// flag. Keys that can not be decoded are formatted as hexadecimal
+// strings prefixed with 0x.
+func (c *cmdContext) formatKey(key []byte) string {
+	switch c.keys {
+	case "hex":
+		return hex.EncodeToString(key)
+	case "natural-reversible":
+		if k, err := boltutils.NaturalDecodeBytes(key); err == nil {
+			return boltutils.NewPath(k).String()
+		}
+		return "0x" + hex.EncodeToString(key)
+	case "time":
+		if t, err := boltutils.ParseTimeBytes(key); err == nil {
+			return t.Format(time.RFC3339Nano)
+		}
+		return "0x" + hex.EncodeToString(key)
+	}
+	return boltutils.NewPath(key).String()
+}
+
+func runGet(c *cmdContext, args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+	p, err := c.parseKeyPath(args[1])
+	if err != nil {
+		return err
+	}
+	return c.db.View(func(tx *bolt.Tx) error {
+		v := boltutils.DeepGet(tx, p.Elements()...)
+		if v == nil {
+			return boltutils.NewNotFoundError(p.String())
+		}
+		if c.hexValue {
+			_, err := fmt.Fprintln(c.stdout, hex.EncodeToString(v))
+			return err
+		}
+		_, err := c.stdout.Write(v)
+		return err
+	})
+}
+
+func runPut(c *cmdContext, args []string) error {
+	if len(args) != 2 && len(args) != 3 {
+		return errUsage
+	}
+	p, err := c.parseKeyPath(args[1])
+	if err != nil {
+		return err
+	}
+	var value []byte
+	if len(args) == 3 {
+		value = []byte(args[2])
+	} else {
+		value, err = io.ReadAll(c.stdin)
+		if err != nil {
+			return fmt.Errorf("read value: %w", err)
+		}
+	}
+	if c.hexValue {
+		value, err = hex.DecodeString(strings.TrimSpace(string(value)))
+		if err != nil {
+			return fmt.Errorf("decode value: %w", err)
+		}
+	}
+	return c.db.Update(func(tx *bolt.Tx) error {
+		_, err := boltutils.DeepPut(tx, !c.noOver, append(p.Elements(), value)...)
+		return err
+	})
+}
+
+func runDelete(c *cmdContext, args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+	if c.bucket {
+		p, err := parsePath(args[1])
+		if err != nil {
+			return err
+		}
+		return c.db.Update(func(tx *bolt.Tx) error {
+			return boltutils.DeepDeleteBucket(tx, c.ensure, p.Buckets...)
+		})
+	}
+	p, err := c.parseKeyPath(args[1])
+	if err != nil {
+		return err
+	}
+	return c.db.Update(func(tx *bolt.Tx) error {
+		return boltutils.DeepDelete(tx, c.ensure, p.Elements()...)
+	})
+}
+
+// bucketArg parses the optional bucket path argument.
+func bucketArg(args []string) (p boltutils.Path, err error) {
+	switch len(args) {
+	case 1:
+		return boltutils.Path{}, nil
+	case 2:
+		return parsePath(args[1])
+	}
+	return p, errUsage
+}
+
+func runLs(c *cmdContext, args []string) error {
+	p, err := bucketArg(args)
+	if err != nil {
+		return err
+	}
+	return c.db.View(func(tx *bolt.Tx) error {
+		if len(p.Buckets) > 0 && boltutils.PathBucket(tx, p) == nil {
+			return boltutils.NewNotFoundError(p.String())
+		}
+		cursor := boltutils.NewDeepCursor(tx, p)
+		for e, _, ok := cursor.Next(); ok; e, _, ok = cursor.Next() {
+			if !e.HasKey() {
+				cursor.SkipBucket()
+				if _, err := fmt.Fprintln(c.stdout, boltutils.NewPath(e.Buckets[len(e.Buckets)-1]).String()+"/"); err != nil {
+					return err
+				}
+				continue
+			}
+			if _, err := fmt.Fprintln(c.stdout, c.formatKey(e.Key)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func runTree(c *cmdContext, args []string) error {
+	p, err := bucketArg(args)
+	if err != nil {
+		return err
+	}
+	return c.db.View(func(tx *bolt.Tx) error {
+		if len(p.Buckets) > 0 && boltutils.PathBucket(tx, p) == nil {
+			return boltutils.NewNotFoundError(p.String())
+		}
+		base := len(p.Buckets)
+		return boltutils.DeepForEach(tx, p, func(e boltutils.Path, value []byte) error {
+			depth := len(e.Buckets) - base
+			if !e.HasKey() {
+				indent := strings.Repeat("  ", depth-1)
+				_, err := fmt.Fprintf(c.stdout, "%s%s/\n", indent, boltutils.NewPath(e.Buckets[len(e.Buckets)-1]))
+				return err
+			}
+			indent := strings.Repeat("  ", depth)
+			_, err := fmt.Fprintf(c.stdout, "%s%s (%d bytes)\n", indent, c.formatKey(e.Key), len(value))
+			return err
+		})
+	})
+}
+
+func (c *cmdContext) format() boltutils.Format {
+	f := boltutils.Format{NDJSON: c.ndjson}
+	if c.hexEnc {
+		f.Encoding = boltutils.EncodingHex
+	}
+	return f
+}
+
+func runExport(c *cmdContext, args []string) error {
+	p, err := bucketArg(args)
+	if err != nil {
+		return err
+	}
+	return c.db.View(func(tx *bolt.Tx) error {
+		return boltutils.Export(tx, c.stdout, p, c.format())
+	})
+}
+
+func runImport(c *cmdContext, args []string) error {
+	p, err := bucketArg(args)
+	if err != nil {
+		return err
+	}
+	opts := &boltutils.ImportOptions{Format: c.format()}
+	switch c.conflict {
+	case "fail":
+		opts.Conflict = boltutils.ConflictFail
+	case "overwrite":
+		opts.Conflict = boltutils.ConflictOverwrite
+	case "merge":
+		opts.Conflict = boltutils.ConflictMerge
+	default:
+		return fmt.Errorf("unknown conflict handling %q", c.conflict)
+	}
+	return c.db.Update(func(tx *bolt.Tx) error {
+		return boltutils.Import(tx, c.stdin, p, opts)
+	})
+}
+
+func runStats(c *cmdContext, args []string) error {
+	p, err := bucketArg(args)
+	if err != nil {
+		return err
+	}
+	return c.db.View(func(tx *bolt.Tx) error {
+		var s bolt.BucketStats
+		if len(p.Buckets) == 0 {
+			cursor := boltutils.NewDeepCursor(tx, p)
+			for e, _, ok := cursor.Next(); ok; e, _, ok = cursor.Next() {
+				cursor.SkipBucket()
+				s.Add(tx.Bucket(e.Buckets[0]).Stats())
+			}
+			fmt.Fprintf(c.stdout, "database size: %d\n", tx.Size())
+			fmt.Fprintf(c.stdout, "page size: %d\n", c.db.Info().PageSize)
+		} else {
+			b := boltutils.PathBucket(tx, p)
+			if b == nil {
+				return boltutils.NewNotFoundError(p.String())
+			}
+			s = b.Stats()
+		}
+		var buf bytes.Buffer
+		fmt.Fprintf(&buf, "buckets: %d (inline: %d)\n", s.BucketN, s.InlineBucketN)
+		fmt.Fprintf(&buf, "keys: %d\n", s.KeyN)
+		fmt.Fprintf(&buf, "depth: %d\n", s.Depth)
+		fmt.Fprintf(&buf, "branch pages: %d (overflow: %d)\n", s.BranchPageN, s.BranchOverflowN)
+		fmt.Fprintf(&buf, "leaf pages: %d (overflow: %d)\n", s.LeafPageN, s.LeafOverflowN)
+		fmt.Fprintf(&buf, "branch bytes: %d allocated, %d in use\n", s.BranchAlloc, s.BranchInuse)
+		fmt.Fprintf(&buf, "leaf bytes: %d allocated, %d in use\n", s.LeafAlloc, s.LeafInuse)
+		_, err := c.stdout.Write(buf.Bytes())
+		return err
+	})
+}
+
+func runCompact(c *cmdContext, args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+	if _, err := os.Stat(args[1]); err == nil {
+		return fmt.Errorf("destination %s already exists", args[1])
+	}
+	src, err := openDB(args[0], true)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := bolt.Open(args[1], 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return err
+	}
+	if err := bolt.Compact(dst, src, 64<<20); err != nil {
+		dst.Close()
+		return fmt.Errorf("compact: %w", err)
+	}
+	return dst.Close()
+}
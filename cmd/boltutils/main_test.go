@@ -0,0 +1,168 @@
+// Copyright (c) 2026, Janoš Guljaš <janos@resenje.org>
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	"resenje.org/boltutils"
+)
+
+// runCmd executes the command with the provided arguments and standard
+// input and returns its standard output.
+func runCmd(t *testing.T, stdin string, wantCode int, args ...string) (stdout string) {
+	t.Helper()
+
+	var out, errOut bytes.Buffer
+	code := run(args, strings.NewReader(stdin), &out, &errOut)
+	if code != wantCode {
+		t.Fatalf("%v: got exit code %d, want %d: %s", args, code, wantCode, errOut.String())
+	}
+	return out.String()
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	db := filepath.Join(dir, "test.db")
+
+	runCmd(t, "", 0, "put", db, "/a/b/k1", "v1")
+	runCmd(t, "v2", 0, "put", db, "a/k2")
+	runCmd(t, "", 0, "put", "-keys", "natural-reversible", db, "a/n/file10", "ten")
+	runCmd(t, "", 0, "put", "-keys", "natural-reversible", db, "a/n/file9", "nine")
+	runCmd(t, "", 0, "put", "-keys", "time", db, "a/t/2026-10-16T12:00:00Z", "noon")
+	runCmd(t, "", 0, "put", "-hex", db, "a/hex", "00ff")
+	runCmd(t, "", 1, "put", "-no-overwrite", db, "a/k2", "v3")
+
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{args: []string{"get", db, "a/b/k1"}, want: "v1"},
+		{args: []string{"get", db, "/a/k2"}, want: "v2"},
+		{args: []string{"get", "-keys", "natural-reversible", db, "a/n/file10"}, want: "ten"},
+		{args: []string{"get", "-hex", db, "a/hex"}, want: "00ff\n"},
+		{args: []string{"ls", db}, want: "a/\n"},
+		{args: []string{"ls", db, "a"}, want: "b/\nhex\nk2\nn/\nt/\n"},
+		{args: []string{"ls", "-keys", "natural-reversible", db, "a/n"}, want: "file9\nfile10\n"},
+		{args: []string{"ls", "-keys", "time", db, "a/t"}, want: "2026-10-16T12:00:00Z\n"},
+		{args: []string{"tree", db, "a/b"}, want: "k1 (2 bytes)\n"},
+	} {
+		if got := runCmd(t, "", 0, tc.args...); got != tc.want {
+			t.Errorf("%v: got %q, want %q", tc.args, got, tc.want)
+		}
+	}
+
+	runCmd(t, "", 1, "get", db, "a/missing")
+	runCmd(t, "", 1, "get", "-keys", "natural", db, "a/n/file10")
+
+	t.Run("Tree", func(t *testing.T) {
+		got := runCmd(t, "", 0, "tree", "-keys", "natural-reversible", db, "a/n")
+		if want := "file9 (4 bytes)\nfile10 (3 bytes)\n"; got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+		got = runCmd(t, "", 0, "tree", db, "a")
+		for _, want := range []string{"b/\n", "  k1 (2 bytes)\n", "k2 (2 bytes)\n", "t/\n"} {
+			if !strings.Contains(got, want) {
+				t.Errorf("got %q, want it to contain %q", got, want)
+			}
+		}
+	})
+
+	t.Run("ExportImport", func(t *testing.T) {
+		data := runCmd(t, "", 0, "export", "-ndjson", db, "a/b")
+		runCmd(t, data, 0, "import", "-ndjson", db, "c")
+		if got := runCmd(t, "", 0, "get", db, "c/k1"); got != "v1" {
+			t.Errorf("got %q, want %q", got, "v1")
+		}
+		runCmd(t, data, 1, "import", "-ndjson", db, "c")
+		runCmd(t, data, 0, "import", "-ndjson", "-conflict", "merge", db, "c")
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		runCmd(t, "", 0, "delete", db, "c/k1")
+		runCmd(t, "", 1, "delete", "-ensure", db, "c/k1")
+		runCmd(t, "", 0, "delete", "-bucket", db, "c")
+		if got := runCmd(t, "", 0, "ls", db); got != "a/\n" {
+			t.Errorf("got %q", got)
+		}
+	})
+
+	t.Run("MetaBucket", func(t *testing.T) {
+		bdb, err := bolt.Open(db, 0o600, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := bdb.Update(func(tx *bolt.Tx) error {
+			if _, err := boltutils.PathPutWithExpiry(tx, true, boltutils.NewKeyPath([]byte("expired"), []byte("m")), []byte("v"), time.Now().Add(-time.Hour)); err != nil {
+				return err
+			}
+			_, err := boltutils.PathPutWithExpiry(tx, true, boltutils.NewKeyPath([]byte("ttl"), []byte("m")), []byte("v"), time.Now().Add(time.Hour))
+			return err
+		}); err != nil {
+			t.Fatalf("bolt db update transaction %s", err)
+		}
+		if err := bdb.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if got := runCmd(t, "", 0, "ls", db); got != "a/\nm/\n" {
+			t.Errorf("got %q", got)
+		}
+		if got := runCmd(t, "", 0, "ls", db, "m"); got != "ttl\n" {
+			t.Errorf("got %q", got)
+		}
+		// Stats of the root do not include the meta bucket.
+		if got := runCmd(t, "", 0, "stats", db); !strings.Contains(got, "buckets: 5 ") {
+			t.Errorf("got %q", got)
+		}
+		for _, args := range [][]string{{"tree"}, {"export"}, {"export", "-ndjson"}} {
+			args = append(args, db)
+			if got := runCmd(t, "", 0, args...); strings.Contains(got, "__boltutils") || !strings.Contains(got, "m") {
+				t.Errorf("%v: got %q", args, got)
+			}
+		}
+		runCmd(t, "", 0, "delete", "-bucket", db, "m")
+	})
+
+	t.Run("Stats", func(t *testing.T) {
+		got := runCmd(t, "", 0, "stats", db, "a")
+		if !strings.Contains(got, "keys: 9\n") {
+			t.Errorf("got %q", got)
+		}
+	})
+
+	t.Run("Compact", func(t *testing.T) {
+		dst := filepath.Join(dir, "compact.db")
+		runCmd(t, "", 0, "compact", db, dst)
+		if got := runCmd(t, "", 0, "get", dst, "a/b/k1"); got != "v1" {
+			t.Errorf("got %q, want %q", got, "v1")
+		}
+		runCmd(t, "", 1, "compact", db, dst)
+	})
+
+	t.Run("ReadOnly", func(t *testing.T) {
+		if err := os.Chmod(db, 0o400); err != nil {
+			t.Fatal(err)
+		}
+		defer os.Chmod(db, 0o600)
+		if got := runCmd(t, "", 0, "get", db, "a/k2"); got != "v2" {
+			t.Errorf("got %q, want %q", got, "v2")
+		}
+	})
+
+	t.Run("Usage", func(t *testing.T) {
+		runCmd(t, "", 2)
+		runCmd(t, "", 2, "unknown")
+		runCmd(t, "", 2, "get", db)
+		runCmd(t, "", 1, "get", filepath.Join(dir, "missing.db"), "a/k")
+		runCmd(t, "", 1, "get", "-keys", "unknown", db, "a/k")
+	})
+}
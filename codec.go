@@ -0,0 +1,112 @@
+// Copyright (c) 2026, Janoš Guljaš <janos@resenje.org>
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package boltutils
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Codec encodes values of type T to byte slices stored in buckets and
+// decodes them back.
+type Codec[T any] interface {
+	Encode(v T) (data []byte, err error)
+	Decode(data []byte) (v T, err error)
+}
+
+// JSONCodec encodes values with encoding/json package.
+type JSONCodec[T any] struct{}
+
+// Encode returns the JSON encoding of v.
+func (JSONCodec[T]) Encode(v T) (data []byte, err error) {
+	return json.Marshal(v)
+}
+
+// Decode parses JSON encoded data.
+func (JSONCodec[T]) Decode(data []byte) (v T, err error) {
+	err = json.Unmarshal(data, &v)
+	return v, err
+}
+
+// GobCodec encodes values with encoding/gob package. Every value is
+// encoded with its own type information.
+type GobCodec[T any] struct{}
+
+// Encode returns the gob encoding of v.
+func (GobCodec[T]) Encode(v T) (data []byte, err error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode parses gob encoded data.
+func (GobCodec[T]) Decode(data []byte) (v T, err error) {
+	err = gob.NewDecoder(bytes.NewReader(data)).Decode(&v)
+	return v, err
+}
+
+// BytesCodec stores byte slices as they are. Decode returns a copy of
+// the data, so that it can be used after the transaction is closed.
+type BytesCodec struct{}
+
+// Encode returns v.
+func (BytesCodec) Encode(v []byte) (data []byte, err error) {
+	return v, nil
+}
+
+// Decode returns a copy of data.
+func (BytesCodec) Decode(data []byte) (v []byte, err error) {
+	return append([]byte{}, data...), nil
+}
+
+// CodecFuncs returns a Codec that uses the provided functions for
+// encoding and decoding.
+func CodecFuncs[T any](encode func(v T) ([]byte, error), decode func(data []byte) (T, error)) Codec[T] {
+	return codecFuncs[T]{encode: encode, decode: decode}
+}
+
+type codecFuncs[T any] struct {
+	encode func(v T) ([]byte, error)
+	decode func(data []byte) (T, error)
+}
+
+func (c codecFuncs[T]) Encode(v T) (data []byte, err error) {
+	return c.encode(v)
+}
+
+func (c codecFuncs[T]) Decode(data []byte) (v T, err error) {
+	return c.decode(data)
+}
+
+// Put encodes the value with the codec and saves it in the same way as
+// PathPut does.
+func Put[T any](tx *bolt.Tx, overwrite bool, p Path, v T, c Codec[T]) (new bool, err error) {
+	data, err := c.Encode(v)
+	if err != nil {
+		return false, fmt.Errorf("bucket %s encode %s: %w", path(p.Buckets...), p.Key, err)
+	}
+	return PathPut(tx, overwrite, p, data)
+}
+
+// Get retrieves the value in the same way as PathGet does and decodes it
+// with the codec. Return value ok is false if the key does not exist.
+func Get[T any](tx *bolt.Tx, p Path, c Codec[T]) (v T, ok bool, err error) {
+	data := PathGet(tx, p)
+	if data == nil {
+		return v, false, nil
+	}
+	v, err = c.Decode(data)
+	if err != nil {
+		return v, false, fmt.Errorf("bucket %s decode %s: %w", path(p.Buckets...), p.Key, err)
+	}
+	return v, true, nil
+}
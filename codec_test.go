@@ -0,0 +1,143 @@
+// Copyright (c) 2026, Janoš Guljaš <janos@resenje.org>
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package boltutils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+type codecTestRecord struct {
+	Name  string
+	Count int
+	Tags  []string
+}
+
+func testCodec[T any](t *testing.T, db testDB, c Codec[T], v T) {
+	t.Helper()
+
+	p := NewKeyPath([]byte("key"), []byte("bucket1"), []byte("bucket2"))
+
+	if err := db.DB.Update(func(tx *bolt.Tx) error {
+		new, err := Put(tx, true, p, v, c)
+		if err != nil {
+			return err
+		}
+		if !new {
+			t.Error("new is not true")
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("bolt db update transaction %s", err)
+	}
+
+	var got T
+	var ok bool
+	if err := db.DB.View(func(tx *bolt.Tx) (err error) {
+		got, ok, err = Get(tx, p, c)
+		return err
+	}); err != nil {
+		t.Fatalf("bolt db view transaction %s", err)
+	}
+	if !ok {
+		t.Fatal("value not found")
+	}
+	if !reflect.DeepEqual(got, v) {
+		t.Errorf("got %v, want %v", got, v)
+	}
+
+	if err := db.DB.Update(func(tx *bolt.Tx) error {
+		return PathDeleteBucket(tx, true, NewPath([]byte("bucket1")))
+	}); err != nil {
+		t.Fatalf("bolt db update transaction %s", err)
+	}
+}
+
+func TestCodec(t *testing.T) {
+	db := newTestDB(t)
+	defer db.Destroy()
+
+	record := codecTestRecord{Name: "test", Count: 42, Tags: []string{"a", "b"}}
+
+	t.Run("JSON", func(t *testing.T) {
+		testCodec[codecTestRecord](t, db, JSONCodec[codecTestRecord]{}, record)
+	})
+
+	t.Run("Gob", func(t *testing.T) {
+		testCodec[codecTestRecord](t, db, GobCodec[codecTestRecord]{}, record)
+	})
+
+	t.Run("Bytes", func(t *testing.T) {
+		testCodec[[]byte](t, db, BytesCodec{}, []byte("value"))
+	})
+
+	t.Run("Funcs", func(t *testing.T) {
+		c := CodecFuncs(func(v uint64) ([]byte, error) {
+			data := make([]byte, 8)
+			binary.BigEndian.PutUint64(data, v)
+			return data, nil
+		}, func(data []byte) (uint64, error) {
+			if len(data) != 8 {
+				return 0, errors.New("invalid length")
+			}
+			return binary.BigEndian.Uint64(data), nil
+		})
+		testCodec(t, db, c, uint64(1234567890))
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		if err := db.DB.View(func(tx *bolt.Tx) error {
+			_, ok, err := Get[codecTestRecord](tx, NewKeyPath([]byte("missing"), []byte("bucket1")), JSONCodec[codecTestRecord]{})
+			if err != nil {
+				return err
+			}
+			if ok {
+				t.Error("ok is not false")
+			}
+			return nil
+		}); err != nil {
+			t.Fatalf("bolt db view transaction %s", err)
+		}
+	})
+
+	t.Run("DecodeError", func(t *testing.T) {
+		p := NewKeyPath([]byte("key"), []byte("bucket1"), []byte("bucket2"))
+		if err := db.DB.Update(func(tx *bolt.Tx) error {
+			_, err := PathPut(tx, true, p, []byte("not json"))
+			return err
+		}); err != nil {
+			t.Fatalf("bolt db update transaction %s", err)
+		}
+		err := db.DB.View(func(tx *bolt.Tx) error {
+			_, _, err := Get[codecTestRecord](tx, p, JSONCodec[codecTestRecord]{})
+			return err
+		})
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if !strings.HasPrefix(err.Error(), "bucket bucket1, bucket2 decode key: ") {
+			t.Errorf("invalid error: %s", err)
+		}
+	})
+
+	t.Run("BytesCopy", func(t *testing.T) {
+		v := []byte("value")
+		got, err := BytesCodec{}.Decode(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got[0] = 'V'
+		if !bytes.Equal(v, []byte("value")) {
+			t.Error("decoded value is not a copy")
+		}
+	})
+}
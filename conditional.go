@@ -0,0 +1,195 @@
+// Copyright (c) 2026, Janoš Guljaš <janos@resenje.org>
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package boltutils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// versionsBucketName is the name of the meta bucket with paths of
+// versioned keys, as encoded by appendMetaKey, as keys and their versions
+// as values.
+var versionsBucketName = []byte("versions")
+
+// ErrMismatch is matched by errors.Is for every MismatchError.
+var ErrMismatch = errors.New("mismatch")
+
+// MismatchError is returned by conditional writes if the current value
+// or version of the key is not the expected one.
+type MismatchError struct {
+	Key string
+	// Segments are bucket names and the key of the path on which the
+	// operation was performed.
+	Segments [][]byte
+	// Op is the operation that returned the error.
+	Op Op
+}
+
+// NewMismatchError returns a new instance of MismatchError.
+func NewMismatchError(key string) *MismatchError { return &MismatchError{Key: key} }
+
+func (e *MismatchError) Error() string { return fmt.Sprintf("key mismatch %q", e.Key) }
+
+// Is returns true if the target is ErrMismatch.
+func (e *MismatchError) Is(target error) bool { return target == ErrMismatch }
+
+// IsMismatchError returns true if provided error is or wraps an error of
+// MismatchError type.
+func IsMismatchError(err error) (yes bool) {
+	var e *MismatchError
+	return errors.As(err, &e)
+}
+
+// DeepCompareAndSwap saves the new value under the key referenced by the
+// path only if its current value is equal to the old value. It returns
+// NotFoundError if the key does not exist and MismatchError if the
+// current value is different.
+func DeepCompareAndSwap(tx *bolt.Tx, p Path, old, new []byte) (err error) {
+	if err := checkValue(tx, OpPut, p, old); err != nil {
+		return err
+	}
+	_, err = PathPut(tx, true, p, new)
+	return err
+}
+
+// DeepPutIfAbsent saves the value under the key referenced by the path
+// only if the key does not exist. It returns ExistsError otherwise.
+func DeepPutIfAbsent(tx *bolt.Tx, p Path, value []byte) (err error) {
+	_, err = PathPut(tx, false, p, value)
+	return err
+}
+
+// DeepDeleteIf deletes the key referenced by the path only if its
+// current value is equal to the expected value. It returns NotFoundError
+// if the key does not exist and MismatchError if the current value is
+// different.
+func DeepDeleteIf(tx *bolt.Tx, p Path, expected []byte) (err error) {
+	if err := checkValue(tx, OpDelete, p, expected); err != nil {
+		return err
+	}
+	return PathDelete(tx, true, p)
+}
+
+// checkValue returns an error if the current value of the key referenced
+// by the path is not equal to the expected value.
+func checkValue(tx *bolt.Tx, op Op, p Path, expected []byte) (err error) {
+	if !p.HasKey() {
+		return fmt.Errorf("path %s has no key", p)
+	}
+	e := p.Elements()
+	current := PathGet(tx, p)
+	if current == nil {
+		return &NotFoundError{Key: path(e...), Segments: e, Op: op, Depth: bucketDepth(tx, p.Buckets)}
+	}
+	if !bytes.Equal(current, expected) {
+		return &MismatchError{Key: path(e...), Segments: e, Op: op}
+	}
+	return nil
+}
+
+// DeepPutVersioned saves the value under the key referenced by the path
+// only if the current version of the key is equal to the provided
+// version, and returns the new version of the key. Versions are taken
+// from a sequence shared by all keys, so they increase with every write,
+// but not by one, and are never reused, even if the key is deleted and
+// saved again. Keys that do not exist have version zero. Keys that exist
+// but are saved only by other functions, such as PathPut, have no version
+// and can not be saved with this function until they are deleted. Other
+// writes to the key change its version. It returns MismatchError if the
+// current version is different or the key has no version.
+func DeepPutVersioned(tx *bolt.Tx, p Path, value []byte, version uint64) (newVersion uint64, err error) {
+	if !p.HasKey() {
+		return 0, fmt.Errorf("path %s has no key", p)
+	}
+	v, current, versioned := keyVersion(tx, p)
+	if current != version || (v != nil && !versioned) {
+		e := p.Elements()
+		return 0, &MismatchError{Key: path(e...), Segments: e, Op: OpPut}
+	}
+	if _, err := PathPut(tx, true, p, value); err != nil {
+		return 0, err
+	}
+	// PathPut changes the version only of keys that already have it.
+	if _, newVersion, versioned = keyVersion(tx, p); versioned {
+		return newVersion, nil
+	}
+	return setVersion(tx, p)
+}
+
+// DeepGetVersioned returns the value and the version of the key
+// referenced by the path. Value is nil and version is zero if the key
+// does not exist. Version is zero if the key has no version.
+func DeepGetVersioned(tx *bolt.Tx, p Path) (value []byte, version uint64) {
+	value, version, _ = keyVersion(tx, p)
+	return value, version
+}
+
+// keyVersion returns the value and the version of the key referenced by
+// the path. Return value versioned is true if the key exists and has a
+// version.
+func keyVersion(tx *bolt.Tx, p Path) (value []byte, version uint64, versioned bool) {
+	value = PathGet(tx, p)
+	if value == nil {
+		return nil, 0, false
+	}
+	versions := metaBucket(tx, versionsBucketName)
+	if versions == nil {
+		return value, 0, false
+	}
+	v := versions.Get(appendMetaKey(nil, p))
+	if len(v) != 8 {
+		return value, 0, false
+	}
+	return value, binary.BigEndian.Uint64(v), true
+}
+
+// setVersion sets the next value from the versions sequence as the
+// version of the key referenced by the path.
+func setVersion(tx *bolt.Tx, p Path) (version uint64, err error) {
+	versions, err := createMetaBucket(tx, versionsBucketName)
+	if err != nil {
+		return 0, err
+	}
+	version, err = versions.NextSequence()
+	if err != nil {
+		return 0, fmt.Errorf("bucket %s sequence: %w", path(MetaBucketName, versionsBucketName), err)
+	}
+	v := make([]byte, 8)
+	binary.BigEndian.PutUint64(v, version)
+	if err := versions.Put(appendMetaKey(nil, p), v); err != nil {
+		return 0, fmt.Errorf("bucket %s put %s: %w", path(MetaBucketName, versionsBucketName), p, err)
+	}
+	return version, nil
+}
+
+// updateVersion sets the new version of the key referenced by the path,
+// if it has a version.
+func updateVersion(tx *bolt.Tx, p Path) (err error) {
+	versions := metaBucket(tx, versionsBucketName)
+	if versions == nil || versions.Get(appendMetaKey(nil, p)) == nil {
+		return nil
+	}
+	_, err = setVersion(tx, p)
+	return err
+}
+
+// clearVersion removes the version of the key referenced by the path, if
+// it is set.
+func clearVersion(tx *bolt.Tx, p Path) (err error) {
+	versions := metaBucket(tx, versionsBucketName)
+	if versions == nil {
+		return nil
+	}
+	if err := versions.Delete(appendMetaKey(nil, p)); err != nil {
+		return fmt.Errorf("bucket %s delete %s: %w", path(MetaBucketName, versionsBucketName), p, err)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+// Copyright (c) 2026, Janoš Guljaš <janos@resenje.org>
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package boltutils
+
+import (
+	"testing"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestConditional(t *testing.T) {
+	db := newTestDB(t)
+	defer db.Destroy()
+
+	p := NewKeyPath([]byte("key"), []byte("a"), []byte("b"))
+
+	if err := db.DB.Update(func(tx *bolt.Tx) error {
+		if err := DeepCompareAndSwap(tx, p, []byte("v0"), []byte("v1")); !IsNotFoundError(err) {
+			t.Errorf("compare and swap: invalid error: %v", err)
+		}
+		if err := DeepDeleteIf(tx, p, []byte("v0")); !IsNotFoundError(err) {
+			t.Errorf("delete if: invalid error: %v", err)
+		}
+		if err := DeepPutIfAbsent(tx, p, []byte("v1")); err != nil {
+			t.Errorf("put if absent: %s", err)
+		}
+		if err := DeepPutIfAbsent(tx, p, []byte("v2")); !IsExistsError(err) {
+			t.Errorf("put if absent: invalid error: %v", err)
+		}
+		if err := DeepCompareAndSwap(tx, p, []byte("v0"), []byte("v2")); !IsMismatchError(err) {
+			t.Errorf("compare and swap: invalid error: %v", err)
+		}
+		if err := DeepCompareAndSwap(tx, p, []byte("v1"), []byte("v2")); err != nil {
+			t.Errorf("compare and swap: %s", err)
+		}
+		if v := string(PathGet(tx, p)); v != "v2" {
+			t.Errorf("got value %q, want %q", v, "v2")
+		}
+		err := DeepDeleteIf(tx, p, []byte("v1"))
+		if !IsMismatchError(err) {
+			t.Errorf("delete if: invalid error: %v", err)
+		}
+		if msg := err.Error(); msg != `key mismatch "a, b, key"` {
+			t.Errorf("got error message %q", msg)
+		}
+		if err := DeepDeleteIf(tx, p, []byte("v2")); err != nil {
+			t.Errorf("delete if: %s", err)
+		}
+		if v := PathGet(tx, p); v != nil {
+			t.Errorf("got value %q, want nil", v)
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("bolt db update transaction %s", err)
+	}
+}
+
+func TestDeepPutVersioned(t *testing.T) {
+	db := newTestDB(t)
+	defer db.Destroy()
+
+	p := NewKeyPath([]byte("key"), []byte("a"))
+
+	if err := db.DB.Update(func(tx *bolt.Tx) error {
+		if v, version := DeepGetVersioned(tx, p); v != nil || version != 0 {
+			t.Errorf("got value %q version %d", v, version)
+		}
+		if _, err := DeepPutVersioned(tx, p, []byte("v1"), 1); !IsMismatchError(err) {
+			t.Errorf("invalid error: %v", err)
+		}
+		for i := uint64(0); i < 3; i++ {
+			version, err := DeepPutVersioned(tx, p, []byte("v"), i)
+			if err != nil {
+				return err
+			}
+			if version != i+1 {
+				t.Errorf("got version %d, want %d", version, i+1)
+			}
+		}
+		if _, err := DeepPutVersioned(tx, p, []byte("v"), 2); !IsMismatchError(err) {
+			t.Errorf("invalid error: %v", err)
+		}
+		if v, version := DeepGetVersioned(tx, p); string(v) != "v" || version != 3 {
+			t.Errorf("got value %q version %d", v, version)
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("bolt db update transaction %s", err)
+	}
+
+	// Version is preserved across transactions and changed by other
+	// writes, and versions are not reused after the key is deleted.
+	if err := db.DB.Update(func(tx *bolt.Tx) error {
+		if _, version := DeepGetVersioned(tx, p); version != 3 {
+			t.Errorf("got version %d, want %d", version, 3)
+		}
+		if _, err := PathPut(tx, true, p, []byte("plain")); err != nil {
+			return err
+		}
+		if v, version := DeepGetVersioned(tx, p); string(v) != "plain" || version != 4 {
+			t.Errorf("got value %q version %d", v, version)
+		}
+		if _, err := DeepPutVersioned(tx, p, []byte("v"), 3); !IsMismatchError(err) {
+			t.Errorf("invalid error: %v", err)
+		}
+		if err := PathDelete(tx, true, p); err != nil {
+			return err
+		}
+		if v, version := DeepGetVersioned(tx, p); v != nil || version != 0 {
+			t.Errorf("got value %q version %d", v, version)
+		}
+		if k, _ := metaBucket(tx, versionsBucketName).Cursor().First(); k != nil {
+			t.Errorf("got version key %x, want none", k)
+		}
+		version, err := DeepPutVersioned(tx, p, []byte("v"), 0)
+		if err != nil {
+			return err
+		}
+		if version != 5 {
+			t.Errorf("got version %d, want %d", version, 5)
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("bolt db update transaction %s", err)
+	}
+
+	// Keys without versions can not be saved with versioned writes.
+	if err := db.DB.Update(func(tx *bolt.Tx) error {
+		p := NewKeyPath([]byte("plain"), []byte("a"))
+		if _, err := PathPut(tx, true, p, []byte("v1")); err != nil {
+			return err
+		}
+		if v, version := DeepGetVersioned(tx, p); string(v) != "v1" || version != 0 {
+			t.Errorf("got value %q version %d", v, version)
+		}
+		if _, err := PathPut(tx, true, p, []byte("v2")); err != nil {
+			return err
+		}
+		if _, err := DeepPutVersioned(tx, p, []byte("v3"), 0); !IsMismatchError(err) {
+			t.Errorf("invalid error: %v", err)
+		}
+		if v := PathGet(tx, p); string(v) != "v2" {
+			t.Errorf("got value %q, want %q", v, "v2")
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("bolt db update transaction %s", err)
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright (c) 2026, Janoš Guljaš <janos@resenje.org>
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package boltutils
+
+import (
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// DeepCopyBucket copies all keys and nested buckets from the bucket
+// referenced by the src path to a new bucket referenced by the dst path,
+// creating all parent buckets of the destination that do not exist. Path
+// keys are ignored. Sequence values of all copied buckets are preserved,
+// as well as FillPercent values set in the same transaction. It returns
+// NotFoundError if the source bucket does not exist and ExistsError if
+// the destination bucket or a key with the same name already exists. A
+// bucket can not be copied into itself or any of its nested buckets.
+// Expired keys are not copied and copied keys keep their expiration
+// times. Versions of copied keys are not copied.
+func DeepCopyBucket(tx *bolt.Tx, src, dst Path) (err error) {
+	if err := checkBucketCopy(tx, src, dst); err != nil {
+		return err
+	}
+	d, err := createNewBucket(tx, dst)
+	if err != nil {
+		return err
+	}
+	if err := copyBucket(PathBucket(tx, src), d); err != nil {
+		return fmt.Errorf("bucket %s copy to %s: %w", path(src.Buckets...), path(dst.Buckets...), err)
+	}
+	return copyBucketExpiry(tx, tx, src, dst, time.Now())
+}
+
+// DeepMoveBucket moves the bucket referenced by the src path with all
+// its keys and nested buckets to the dst path, with the same behaviour
+// as DeepCopyBucket, after which the source bucket is deleted. Unlike
+// with DeepCopyBucket, versions of keys are moved together with the
+// keys.
+func DeepMoveBucket(tx *bolt.Tx, src, dst Path) (err error) {
+	if err := DeepCopyBucket(tx, src, dst); err != nil {
+		return err
+	}
+	if err := moveBucketVersions(tx, src, dst); err != nil {
+		return err
+	}
+	return PathDeleteBucket(tx, true, src)
+}
+
+// DeepRenameBucket changes the name of the bucket referenced by the path
+// to the provided name, keeping it in the same parent bucket. It has the
+// same behaviour as DeepMoveBucket.
+func DeepRenameBucket(tx *bolt.Tx, p Path, name []byte) (err error) {
+	length := len(p.Buckets)
+	if length < 1 {
+		return fmt.Errorf("insufficient number of buckets %d < 1", length)
+	}
+	return DeepMoveBucket(tx, p, NewPath(p.Buckets[:length-1]...).Child(name))
+}
+
+// checkBucketCopy validates the source and destination paths of a bucket
+// copy.
+func checkBucketCopy(tx *bolt.Tx, src, dst Path) (err error) {
+	if length := len(src.Buckets); length < 1 {
+		return fmt.Errorf("insufficient number of buckets %d < 1", length)
+	}
+	if length := len(dst.Buckets); length < 1 {
+		return fmt.Errorf("insufficient number of buckets %d < 1", length)
+	}
+	if depth := bucketDepth(tx, src.Buckets); depth < len(src.Buckets) {
+		return &NotFoundError{Key: path(src.Buckets...), Segments: src.Buckets, Op: OpCopyBucket, Depth: depth}
+	}
+	if dst.HasPrefix(src) {
+		return fmt.Errorf("bucket %s copy into its own bucket %s", path(src.Buckets...), path(dst.Buckets...))
+	}
+	return nil
+}
+
+// createNewBucket creates the bucket referenced by the path and all its
+// parent buckets that do not exist. It returns ExistsError if the bucket
+// or a key with the same name exists in the parent bucket.
+func createNewBucket(tx *bolt.Tx, p Path) (bucket *bolt.Bucket, err error) {
+	length := len(p.Buckets)
+	name := p.Buckets[length-1]
+	if length == 1 {
+		bucket, err = tx.CreateBucket(name)
+	} else {
+		var parent *bolt.Bucket
+		parent, err = PathCreateBucketIfNotExists(tx, NewPath(p.Buckets[:length-1]...))
+		if err != nil {
+			return nil, err
+		}
+		bucket, err = parent.CreateBucket(name)
+	}
+	switch err {
+	case nil:
+		return bucket, nil
+	case bolt.ErrBucketExists, bolt.ErrIncompatibleValue:
+		return nil, &ExistsError{Key: path(p.Buckets...), Segments: p.Buckets, Op: OpCopyBucket, Depth: length, Err: err}
+	}
+	return nil, &PathError{Op: OpCreateBucket, Segments: p.Buckets, Depth: length - 1, Err: err}
+}
+
+// copyBucket recursively copies all keys and nested buckets from the src
+// to the dst bucket, together with FillPercent and sequence values.
+func copyBucket(src, dst *bolt.Bucket) (err error) {
+	dst.FillPercent = src.FillPercent
+	if err := dst.SetSequence(src.Sequence()); err != nil {
+		return err
+	}
+	c := src.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		if v == nil {
+			if s := src.Bucket(k); s != nil {
+				d, err := dst.CreateBucket(k)
+				if err != nil {
+					return fmt.Errorf("create %s: %w", k, err)
+				}
+				if err := copyBucket(s, d); err != nil {
+					return fmt.Errorf("%s: %w", k, err)
+				}
+				continue
+			}
+		}
+		if err := dst.Put(k, v); err != nil {
+			return fmt.Errorf("put %s: %w", k, err)
+		}
+	}
+	return nil
+}
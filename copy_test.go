@@ -0,0 +1,239 @@
+// Copyright (c) 2026, Janoš Guljaš <janos@resenje.org>
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package boltutils
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestDeepCopyBucket(t *testing.T) {
+	db := newTestDB(t)
+	defer db.Destroy()
+
+	src := NewPath([]byte("a"), []byte("src"))
+	if err := db.DB.Update(func(tx *bolt.Tx) error {
+		if _, err := PathPut(tx, false, src.WithKey([]byte("k1")), []byte("v1")); err != nil {
+			return err
+		}
+		if _, err := PathPut(tx, false, src.Child([]byte("b")).WithKey([]byte("k2")), []byte("v2")); err != nil {
+			return err
+		}
+		if _, err := PathCreateBucketIfNotExists(tx, src.Child([]byte("empty"))); err != nil {
+			return err
+		}
+		if err := PathBucket(tx, src).SetSequence(10); err != nil {
+			return err
+		}
+		return PathBucket(tx, src.Child([]byte("b"))).SetSequence(20)
+	}); err != nil {
+		t.Fatalf("bolt db update transaction %s", err)
+	}
+
+	dst := NewPath([]byte("c"), []byte("dst"))
+	if err := db.DB.Update(func(tx *bolt.Tx) error {
+		// FillPercent is not persisted, so it is set in the same
+		// transaction.
+		PathBucket(tx, src).FillPercent = 0.9
+		if err := DeepCopyBucket(tx, src, dst); err != nil {
+			return err
+		}
+		b := PathBucket(tx, dst)
+		if b.FillPercent != 0.9 {
+			t.Errorf("got fill percent %v, want %v", b.FillPercent, 0.9)
+		}
+		if s := b.Sequence(); s != 10 {
+			t.Errorf("got sequence %v, want %v", s, 10)
+		}
+		if s := PathBucket(tx, dst.Child([]byte("b"))).Sequence(); s != 20 {
+			t.Errorf("got nested sequence %v, want %v", s, 20)
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("bolt db update transaction %s", err)
+	}
+
+	want := []string{"b/", "b/k2=v2", "empty/", "k1=v1"}
+	for _, p := range []Path{src, dst} {
+		var got []string
+		for _, s := range dump(t, db, p) {
+			got = append(got, s[len(p.String())+1:])
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %q, want %q", p, got, want)
+		}
+	}
+
+	t.Run("Exists", func(t *testing.T) {
+		if err := db.DB.Update(func(tx *bolt.Tx) error {
+			return DeepCopyBucket(tx, src, dst)
+		}); !IsExistsError(err) {
+			t.Errorf("invalid error: %v", err)
+		}
+		// Key with the same name as the destination bucket.
+		if err := db.DB.Update(func(tx *bolt.Tx) error {
+			return DeepCopyBucket(tx, NewPath([]byte("c")), src.Child([]byte("k1")))
+		}); !IsExistsError(err) {
+			t.Errorf("invalid error: %v", err)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		if err := db.DB.Update(func(tx *bolt.Tx) error {
+			return DeepCopyBucket(tx, NewPath([]byte("missing")), NewPath([]byte("x")))
+		}); !IsNotFoundError(err) {
+			t.Errorf("invalid error: %v", err)
+		}
+	})
+
+	t.Run("OwnSubtree", func(t *testing.T) {
+		for _, p := range []Path{src, src.Child([]byte("b")), src.Child([]byte("x"))} {
+			if err := db.DB.Update(func(tx *bolt.Tx) error {
+				return DeepCopyBucket(tx, src, p)
+			}); err == nil || IsExistsError(err) {
+				t.Errorf("%s: invalid error: %v", p, err)
+			}
+		}
+	})
+
+	t.Run("Expiry", func(t *testing.T) {
+		src := NewPath([]byte("ttl"))
+		dst := NewPath([]byte("ttl-copy"))
+		expires := time.Now().Add(time.Hour)
+		if err := db.DB.Update(func(tx *bolt.Tx) error {
+			if _, err := PathPutWithExpiry(tx, false, src.Child([]byte("b")).WithKey([]byte("live")), []byte("v1"), expires); err != nil {
+				return err
+			}
+			if _, err := PathPutWithExpiry(tx, false, src.Child([]byte("b")).WithKey([]byte("expired")), []byte("v2"), time.Now().Add(-time.Hour)); err != nil {
+				return err
+			}
+			return DeepCopyBucket(tx, src, dst)
+		}); err != nil {
+			t.Fatalf("bolt db update transaction %s", err)
+		}
+		if err := db.DB.View(func(tx *bolt.Tx) error {
+			if v := PathBucket(tx, dst.Child([]byte("b"))).Get([]byte("expired")); v != nil {
+				t.Errorf("got expired key value %q", v)
+			}
+			if e, ok := PathExpiry(tx, dst.Child([]byte("b")).WithKey([]byte("live"))); !ok || !e.Equal(expires) {
+				t.Errorf("got expiry %s, want %s", e, expires)
+			}
+			if _, ok := PathExpiry(tx, dst.Child([]byte("b")).WithKey([]byte("expired"))); ok {
+				t.Error("got expiry of expired key")
+			}
+			// Source keys keep their expiration times.
+			if e, ok := PathExpiry(tx, src.Child([]byte("b")).WithKey([]byte("live"))); !ok || !e.Equal(expires) {
+				t.Errorf("got source expiry %s, want %s", e, expires)
+			}
+			return nil
+		}); err != nil {
+			t.Fatalf("bolt db view transaction %s", err)
+		}
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		if err := db.DB.Update(func(tx *bolt.Tx) error {
+			return DeepCopyBucket(tx, Path{}, dst)
+		}); err == nil {
+			t.Error("expected error")
+		}
+		if err := db.DB.Update(func(tx *bolt.Tx) error {
+			return DeepCopyBucket(tx, src, Path{})
+		}); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
+
+func TestDeepMoveBucket(t *testing.T) {
+	db := newTestDB(t)
+	defer db.Destroy()
+
+	expires := time.Now().Add(time.Hour)
+
+	if err := db.DB.Update(func(tx *bolt.Tx) error {
+		if _, err := DeepPut(tx, false, []byte("a"), []byte("b"), []byte("k1"), []byte("v1")); err != nil {
+			return err
+		}
+		if _, err := DeepPut(tx, false, []byte("a"), []byte("b"), []byte("c"), []byte("k2"), []byte("v2")); err != nil {
+			return err
+		}
+		if _, err := PathPutWithExpiry(tx, false, NewKeyPath([]byte("k3"), []byte("a"), []byte("b"), []byte("c")), []byte("v3"), expires); err != nil {
+			return err
+		}
+		if _, err := DeepPutVersioned(tx, NewKeyPath([]byte("k4"), []byte("a"), []byte("b")), []byte("v4"), 0); err != nil {
+			return err
+		}
+		k5 := NewKeyPath([]byte("k5"), []byte("a"), []byte("b"))
+		if _, err := DeepPutVersioned(tx, k5, []byte("v5"), 0); err != nil {
+			return err
+		}
+		return setExpiry(tx, k5, time.Now().Add(-time.Hour))
+	}); err != nil {
+		t.Fatalf("bolt db update transaction %s", err)
+	}
+
+	if err := db.DB.Update(func(tx *bolt.Tx) error {
+		return DeepMoveBucket(tx, NewPath([]byte("a"), []byte("b")), NewPath([]byte("d")))
+	}); err != nil {
+		t.Fatalf("move: %s", err)
+	}
+	dumpAll := func() []string {
+		return append(dump(t, db, NewPath([]byte("a"))), dump(t, db, NewPath([]byte("d")))...)
+	}
+	want := []string{"d/c/", "d/c/k2=v2", "d/c/k3=v3", "d/k1=v1", "d/k4=v4"}
+	if got := dumpAll(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	if err := db.DB.Update(func(tx *bolt.Tx) error {
+		return DeepRenameBucket(tx, NewPath([]byte("d"), []byte("c")), []byte("e"))
+	}); err != nil {
+		t.Fatalf("rename: %s", err)
+	}
+	want = []string{"d/e/", "d/e/k2=v2", "d/e/k3=v3", "d/k1=v1", "d/k4=v4"}
+	if got := dumpAll(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// Expiration times and versions are moved with keys, and expired
+	// keys are dropped together with their meta entries.
+	if err := db.DB.View(func(tx *bolt.Tx) error {
+		if e, ok := PathExpiry(tx, NewKeyPath([]byte("k3"), []byte("d"), []byte("e"))); !ok || !e.Equal(expires) {
+			t.Errorf("got expiry %s, want %s", e, expires)
+		}
+		if _, v := DeepGetVersioned(tx, NewKeyPath([]byte("k4"), []byte("d"))); v != 1 {
+			t.Errorf("got version %d, want %d", v, 1)
+		}
+		for _, name := range [][]byte{ttlKeysBucketName, ttlIndexBucketName, versionsBucketName} {
+			if n := metaBucket(tx, name).Stats().KeyN; n != 1 {
+				t.Errorf("bucket %s: got %d entries, want %d", name, n, 1)
+			}
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("bolt db view transaction %s", err)
+	}
+
+	if err := db.DB.Update(func(tx *bolt.Tx) error {
+		return DeepRenameBucket(tx, NewPath([]byte("d")), []byte("a"))
+	}); !IsExistsError(err) {
+		t.Errorf("invalid error: %v", err)
+	}
+	if err := db.DB.Update(func(tx *bolt.Tx) error {
+		return DeepRenameBucket(tx, NewPath([]byte("missing")), []byte("x"))
+	}); !IsNotFoundError(err) {
+		t.Errorf("invalid error: %v", err)
+	}
+	if err := db.DB.Update(func(tx *bolt.Tx) error {
+		return DeepRenameBucket(tx, Path{}, []byte("x"))
+	}); err == nil {
+		t.Error("expected error")
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright (c) 2026, Janoš Guljaš <janos@resenje.org>
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package boltutils
+
+import (
+	"bytes"
+	"errors"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// SkipBucket is used as a return value from DeepForEachFunc to indicate
+// that the bucket named in the call is to be skipped. It is not
+// returned as an error by DeepForEach.
+var SkipBucket = errors.New("skip this bucket")
+
+// SkipAll is used as a return value from DeepForEachFunc to indicate
+// that all remaining keys and buckets are to be skipped. It is not
+// returned as an error by DeepForEach.
+var SkipAll = errors.New("skip everything and stop the iteration")
+
+// DeepCursor iterates depth-first over all keys and buckets nested
+// under a bucket. Buckets are returned before their content. Expired
+// keys are not returned.
+type DeepCursor struct {
+	tx     *bolt.Tx
+	now    time.Time
+	base   [][]byte
+	frames []deepCursorFrame
+	// descend holds the bucket returned by the last call to Next that
+	// will be iterated over on the next call, unless skipped.
+	descend     *bolt.Bucket
+	descendName []byte
+	// expiring is true if any key in the database has an expiration
+	// time.
+	expiring bool
+}
+
+type deepCursorFrame struct {
+	name   []byte
+	cursor *bolt.Cursor
+	// started is false until the first key is read from the cursor.
+	started bool
+}
+
+// NewDeepCursor returns a DeepCursor for the bucket referenced by the
+// path. Path key is ignored. If the path has no buckets, top level
+// buckets are iterated, except the meta bucket. If the bucket does not
+// exist, the cursor will not return any elements.
+func NewDeepCursor(tx *bolt.Tx, p Path) (c *DeepCursor) {
+	c = &DeepCursor{
+		tx:       tx,
+		now:      time.Now(),
+		base:     p.Buckets,
+		expiring: metaBucket(tx, ttlKeysBucketName) != nil,
+	}
+	if len(p.Buckets) == 0 {
+		c.frames = []deepCursorFrame{{cursor: tx.Cursor()}}
+		return c
+	}
+	bucket := DeepBucket(tx, p.Buckets...)
+	if bucket == nil {
+		return c
+	}
+	c.frames = []deepCursorFrame{{cursor: bucket.Cursor()}}
+	return c
+}
+
+// Next moves the cursor to the next key or bucket and returns its
+// full path and value. If the element is a bucket, path Key is nil
+// and the value is nil. Bucket content is returned by subsequent
+// calls to Next, unless SkipBucket is called. Return value ok is false
+// when there are no more elements.
+func (c *DeepCursor) Next() (p Path, value []byte, ok bool) {
+	if c.descend != nil {
+		c.frames = append(c.frames, deepCursorFrame{
+			name:   c.descendName,
+			cursor: c.descend.Cursor(),
+		})
+		c.descend = nil
+		c.descendName = nil
+	}
+	for len(c.frames) > 0 {
+		f := &c.frames[len(c.frames)-1]
+		var k, v []byte
+		if f.started {
+			k, v = f.cursor.Next()
+		} else {
+			k, v = f.cursor.First()
+			f.started = true
+		}
+		if k == nil {
+			c.frames = c.frames[:len(c.frames)-1]
+			continue
+		}
+		if v == nil {
+			if b := f.cursor.Bucket().Bucket(k); b != nil {
+				if len(c.base) == 0 && len(c.frames) == 1 && bytes.Equal(k, MetaBucketName) {
+					continue
+				}
+				c.descend = b
+				c.descendName = k
+				return Path{Buckets: c.buckets(k)}, nil, true
+			}
+		}
+		p = Path{Buckets: c.buckets(nil), Key: k}
+		if c.expiring && isExpired(c.tx, p, c.now) {
+			continue
+		}
+		return p, v, true
+	}
+	return Path{}, nil, false
+}
+
+// SkipBucket prevents iteration over the content of the bucket that
+// was returned by the last call to Next.
+func (c *DeepCursor) SkipBucket() {
+	c.descend = nil
+	c.descendName = nil
+}
+
+// buckets returns the names of the base bucket and all buckets that
+// are currently iterated over, with an optional name appended.
+func (c *DeepCursor) buckets(name []byte) (buckets [][]byte) {
+	buckets = make([][]byte, 0, len(c.base)+len(c.frames))
+	buckets = append(buckets, c.base...)
+	for _, f := range c.frames[1:] {
+		buckets = append(buckets, f.name)
+	}
+	if name != nil {
+		buckets = append(buckets, name)
+	}
+	return buckets
+}
+
+// DeepForEachFunc is the type of the function called by DeepForEach for
+// every key and bucket. For buckets, path Key is nil and the value is
+// nil. If the function returns SkipBucket when invoked on a bucket,
+// DeepForEach skips the bucket's content. If the function returns
+// SkipAll, DeepForEach stops the iteration and returns nil. Any other
+// non-nil error stops the iteration and it is returned by DeepForEach.
+type DeepForEachFunc func(p Path, value []byte) error
+
+// DeepForEach walks depth-first over all keys and buckets nested under
+// the bucket referenced by the path, calling fn for each of them, in the
+// same way as DeepCursor.
+func DeepForEach(tx *bolt.Tx, p Path, fn DeepForEachFunc) (err error) {
+	c := NewDeepCursor(tx, p)
+	for {
+		p, v, ok := c.Next()
+		if !ok {
+			return nil
+		}
+		if err := fn(p, v); err != nil {
+			switch err {
+			case SkipBucket:
+				if !p.HasKey() {
+					c.SkipBucket()
+				}
+			case SkipAll:
+				return nil
+			default:
+				return err
+			}
+		}
+	}
+}
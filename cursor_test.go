@@ -0,0 +1,170 @@
+// Copyright (c) 2026, Janoš Guljaš <janos@resenje.org>
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package boltutils
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestDeepForEach(t *testing.T) {
+	db := newTestDB(t)
+	defer db.Destroy()
+
+	if err := db.DB.Update(func(tx *bolt.Tx) error {
+		for _, elements := range [][]string{
+			{"a", "k1", "v1"},
+			{"a", "b", "k2", "v2"},
+			{"a", "b", "c", "k3", "v3"},
+			{"a", "b", "k4", "v4"},
+			{"a", "d", "k5", "v5"},
+			{"e", "k6", "v6"},
+		} {
+			e := make([][]byte, 0, len(elements))
+			for _, s := range elements {
+				e = append(e, []byte(s))
+			}
+			if _, err := DeepPut(tx, false, e...); err != nil {
+				return err
+			}
+		}
+		// Expired keys and the meta bucket with their expiration times
+		// are not walked over.
+		for _, p := range []Path{
+			NewKeyPath([]byte("k0"), []byte("a"), []byte("b"), []byte("c")),
+			NewKeyPath([]byte("k7"), []byte("e")),
+		} {
+			if _, err := PathPutWithExpiry(tx, false, p, []byte("expired"), time.Now().Add(-time.Second)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("bolt db update transaction %s", err)
+	}
+
+	walk := func(p Path, fn func(p Path) error) (got []string, err error) {
+		err = db.DB.View(func(tx *bolt.Tx) error {
+			return DeepForEach(tx, p, func(p Path, value []byte) error {
+				if p.HasKey() {
+					got = append(got, p.String()+"="+string(value))
+				} else {
+					got = append(got, p.String()+"/")
+				}
+				if fn != nil {
+					return fn(p)
+				}
+				return nil
+			})
+		})
+		return got, err
+	}
+
+	t.Run("All", func(t *testing.T) {
+		got, err := walk(Path{}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []string{
+			"a/",
+			"a/b/",
+			"a/b/c/",
+			"a/b/c/k3=v3",
+			"a/b/k2=v2",
+			"a/b/k4=v4",
+			"a/d/",
+			"a/d/k5=v5",
+			"a/k1=v1",
+			"e/",
+			"e/k6=v6",
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+
+	t.Run("Nested", func(t *testing.T) {
+		got, err := walk(NewPath([]byte("a"), []byte("b")), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []string{
+			"a/b/c/",
+			"a/b/c/k3=v3",
+			"a/b/k2=v2",
+			"a/b/k4=v4",
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+
+	t.Run("SkipBucket", func(t *testing.T) {
+		got, err := walk(NewPath([]byte("a")), func(p Path) error {
+			if !p.HasKey() && string(p.Buckets[len(p.Buckets)-1]) == "b" {
+				return SkipBucket
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []string{
+			"a/b/",
+			"a/d/",
+			"a/d/k5=v5",
+			"a/k1=v1",
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+
+	t.Run("SkipAll", func(t *testing.T) {
+		got, err := walk(Path{}, func(p Path) error {
+			if p.HasKey() {
+				return SkipAll
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []string{
+			"a/",
+			"a/b/",
+			"a/b/c/",
+			"a/b/c/k3=v3",
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		errTest := errors.New("test")
+		_, err := walk(Path{}, func(p Path) error {
+			return errTest
+		})
+		if err != errTest {
+			t.Errorf("got error %v, want %v", err, errTest)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		got, err := walk(NewPath([]byte("missing")), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 0 {
+			t.Errorf("got %q, want none", got)
+		}
+	})
+}
@@ -0,0 +1,198 @@
+// Copyright (c) 2026, Janoš Guljaš <janos@resenje.org>
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package boltutils
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// DBOptions holds optional parameters for DB.
+type DBOptions struct {
+	// RetryErrors are errors on which a transaction is retried. Errors
+	// are matched with errors.Is.
+	RetryErrors []error
+	// MaxRetries is the maximal number of retries of a transaction
+	// that returned one of RetryErrors. Default is 3.
+	MaxRetries int
+	// RetryDelay is the time between two attempts.
+	RetryDelay time.Duration
+	// Batch makes all write transactions run with bolt.DB.Batch, which
+	// combines concurrent writes into a single transaction. Functions
+	// passed to Update may be called more than once.
+	Batch bool
+	// Journal makes all changes made with DB methods also be recorded
+	// in the journal, in the same transaction. Journal entries are read
+	// with ReadJournal.
+	Journal bool
+}
+
+// DB wraps a Bolt database to provide methods that run each operation
+// on a path in its own transaction.
+type DB struct {
+	db          *bolt.DB
+	retryErrors []error
+	maxRetries  int
+	retryDelay  time.Duration
+	batch       bool
+	journal     bool
+
+	mu            sync.Mutex
+	subscriptions []*Subscription
+	subscribers   int32 // accessed atomically
+}
+
+// NewDB returns a new DB that uses the provided Bolt database.
+func NewDB(db *bolt.DB, opts *DBOptions) (d *DB) {
+	if opts == nil {
+		opts = new(DBOptions)
+	}
+	d = &DB{
+		db:          db,
+		retryErrors: opts.RetryErrors,
+		maxRetries:  opts.MaxRetries,
+		retryDelay:  opts.RetryDelay,
+		batch:       opts.Batch,
+		journal:     opts.Journal,
+	}
+	if d.maxRetries <= 0 {
+		d.maxRetries = 3
+	}
+	return d
+}
+
+// Bolt returns the underlying Bolt database.
+func (d *DB) Bolt() *bolt.DB {
+	return d.db
+}
+
+// View runs the function in a read-only transaction. It returns the
+// context error if the context is done before the transaction starts.
+func (d *DB) View(ctx context.Context, fn func(tx *bolt.Tx) error) (err error) {
+	return d.run(ctx, d.db.View, fn)
+}
+
+// Update runs the function in a read-write transaction. It returns the
+// context error if the context is done before the transaction starts.
+func (d *DB) Update(ctx context.Context, fn func(tx *bolt.Tx) error) (err error) {
+	if d.batch {
+		return d.run(ctx, d.db.Batch, fn)
+	}
+	return d.run(ctx, d.db.Update, fn)
+}
+
+func (d *DB) run(ctx context.Context, txFunc func(func(*bolt.Tx) error) error, fn func(tx *bolt.Tx) error) (err error) {
+	for attempt := 0; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		err = txFunc(fn)
+		if err == nil || attempt >= d.maxRetries || !d.retryable(err) {
+			return err
+		}
+		if d.retryDelay > 0 {
+			timer := time.NewTimer(d.retryDelay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+	}
+}
+
+func (d *DB) retryable(err error) bool {
+	for _, e := range d.retryErrors {
+		if errors.Is(err, e) {
+			return true
+		}
+	}
+	return false
+}
+
+// Get returns a copy of the value of the key referenced by the path, in
+// the same way as PathGet. It returns nil if the key does not exist.
+func (d *DB) Get(ctx context.Context, p Path) (value []byte, err error) {
+	err = d.View(ctx, func(tx *bolt.Tx) error {
+		if v := PathGet(tx, p); v != nil {
+			value = append([]byte{}, v...)
+		}
+		return nil
+	})
+	return value, err
+}
+
+// PutOptions holds optional parameters for DB.Put.
+type PutOptions struct {
+	// NoOverwrite makes Put return ExistsError if the key exists.
+	NoOverwrite bool
+	// TTL is the duration after which the key expires, if it is
+	// positive.
+	TTL time.Duration
+}
+
+// Put saves the value under the key referenced by the path, in the same
+// way as PathPut or PathPutWithExpiry if TTL is set. Return value new
+// will be true if the key is put for the first time.
+func (d *DB) Put(ctx context.Context, p Path, value []byte, opts *PutOptions) (new bool, err error) {
+	if opts == nil {
+		opts = &PutOptions{}
+	}
+	err = d.Update(ctx, func(tx *bolt.Tx) (err error) {
+		var expires time.Time
+		if opts.TTL > 0 {
+			expires = time.Now().Add(opts.TTL)
+		}
+		new, err = d.pathPut(tx, !opts.NoOverwrite, p, value, expires)
+		return err
+	})
+	return new, err
+}
+
+// Delete deletes the key referenced by the path in the same way as
+// PathDelete.
+func (d *DB) Delete(ctx context.Context, ensure bool, p Path) (err error) {
+	return d.Update(ctx, func(tx *bolt.Tx) error {
+		return d.pathDelete(tx, ensure, p)
+	})
+}
+
+// DeleteBucket deletes the bucket referenced by the path in the same way
+// as PathDeleteBucket.
+func (d *DB) DeleteBucket(ctx context.Context, ensure bool, p Path) (err error) {
+	return d.Update(ctx, func(tx *bolt.Tx) error {
+		return d.pathDeleteBucket(tx, ensure, p)
+	})
+}
+
+// ListEntry is a key with its value returned by DB.List.
+type ListEntry struct {
+	Key   []byte
+	Value []byte
+}
+
+// List returns copies of keys and values in the bucket referenced by the
+// path, with keys that start with the path key, in the same way as
+// DeepScanPrefix. Nested buckets and expired keys are not listed.
+func (d *DB) List(ctx context.Context, p Path, opts *ScanOptions) (entries []ListEntry, err error) {
+	err = d.View(ctx, func(tx *bolt.Tx) error {
+		entries = entries[:0]
+		s := DeepScanPrefix(tx, p, opts)
+		for s.Next() {
+			entries = append(entries, ListEntry{
+				Key:   append([]byte{}, s.Key()...),
+				Value: append([]byte{}, s.Value()...),
+			})
+		}
+		return s.Err()
+	})
+	return entries, err
+}
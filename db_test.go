@@ -0,0 +1,214 @@
+// Copyright (c) 2026, Janoš Guljaš <janos@resenje.org>
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package boltutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestDB(t *testing.T) {
+	tdb := newTestDB(t)
+	defer tdb.Destroy()
+
+	db := NewDB(tdb.DB, nil)
+	if db.Bolt() != tdb.DB {
+		t.Error("invalid bolt database")
+	}
+	ctx := context.Background()
+	p := NewKeyPath([]byte("k1"), []byte("a"), []byte("b"))
+
+	if v, err := db.Get(ctx, p); err != nil || v != nil {
+		t.Errorf("got value %q, error %v", v, err)
+	}
+	if new, err := db.Put(ctx, p, []byte("v1"), nil); err != nil || !new {
+		t.Errorf("put: new %v, error %v", new, err)
+	}
+	if _, err := db.Put(ctx, p, []byte("v2"), &PutOptions{NoOverwrite: true}); !IsExistsError(err) {
+		t.Errorf("put: invalid error: %v", err)
+	}
+	if v, err := db.Get(ctx, p); err != nil || string(v) != "v1" {
+		t.Errorf("got value %q, error %v", v, err)
+	}
+	if _, err := db.Put(ctx, p.Bucket().WithKey([]byte("k2")), []byte("v2"), &PutOptions{TTL: time.Hour}); err != nil {
+		t.Fatalf("put: %s", err)
+	}
+	if err := db.View(ctx, func(tx *bolt.Tx) error {
+		if _, ok := PathExpiry(tx, p.Bucket().WithKey([]byte("k2"))); !ok {
+			t.Error("expiry not set")
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("view: %s", err)
+	}
+	if _, err := db.Put(ctx, p.Bucket().WithKey([]byte("x")), []byte("v3"), nil); err != nil {
+		t.Fatalf("put: %s", err)
+	}
+	if err := db.Update(ctx, func(tx *bolt.Tx) error {
+		_, err := PathPutWithExpiry(tx, true, p.Bucket().WithKey([]byte("k0")), []byte("v0"), time.Now().Add(-time.Second))
+		return err
+	}); err != nil {
+		t.Fatalf("update: %s", err)
+	}
+
+	entries, err := db.List(ctx, p.Bucket().WithKey([]byte("k")), nil)
+	if err != nil {
+		t.Fatalf("list: %s", err)
+	}
+	var got []string
+	for _, e := range entries {
+		got = append(got, string(e.Key)+"="+string(e.Value))
+	}
+	if fmt.Sprint(got) != "[k1=v1 k2=v2]" {
+		t.Errorf("got entries %q", got)
+	}
+	// Expired keys are not counted by offset and limit.
+	entries, err = db.List(ctx, p.Bucket(), &ScanOptions{Offset: 1, Limit: 1})
+	if err != nil {
+		t.Fatalf("list: %s", err)
+	}
+	if len(entries) != 1 || string(entries[0].Key) != "k2" {
+		t.Errorf("got entries %q", entries)
+	}
+
+	if err := db.Delete(ctx, true, p); err != nil {
+		t.Errorf("delete: %s", err)
+	}
+	if err := db.Delete(ctx, true, p); !errors.Is(err, ErrNotFound) {
+		t.Errorf("delete: invalid error: %v", err)
+	}
+	if err := db.DeleteBucket(ctx, true, p.Bucket()); err != nil {
+		t.Errorf("delete bucket: %s", err)
+	}
+	if err := db.DeleteBucket(ctx, true, p.Bucket()); !errors.Is(err, ErrNotFound) {
+		t.Errorf("delete bucket: invalid error: %v", err)
+	}
+
+	t.Run("Cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+		if _, err := db.Put(ctx, p, []byte("v"), nil); err != context.Canceled {
+			t.Errorf("put: invalid error: %v", err)
+		}
+		if _, err := db.Get(ctx, p); err != context.Canceled {
+			t.Errorf("get: invalid error: %v", err)
+		}
+		if _, err := db.List(ctx, p.Bucket(), nil); err != context.Canceled {
+			t.Errorf("list: invalid error: %v", err)
+		}
+		if err := db.Delete(ctx, false, p); err != context.Canceled {
+			t.Errorf("delete: invalid error: %v", err)
+		}
+		if err := db.DeleteBucket(ctx, false, p.Bucket()); err != context.Canceled {
+			t.Errorf("delete bucket: invalid error: %v", err)
+		}
+	})
+}
+
+func TestDBRetry(t *testing.T) {
+	tdb := newTestDB(t)
+	defer tdb.Destroy()
+
+	errTransient := errors.New("transient")
+	db := NewDB(tdb.DB, &DBOptions{
+		RetryErrors: []error{errTransient},
+		MaxRetries:  2,
+	})
+	ctx := context.Background()
+
+	var calls int
+	fail := func(n int) func(tx *bolt.Tx) error {
+		calls = 0
+		return func(tx *bolt.Tx) error {
+			calls++
+			if calls <= n {
+				return fmt.Errorf("attempt %d: %w", calls, errTransient)
+			}
+			return nil
+		}
+	}
+
+	if err := db.Update(ctx, fail(2)); err != nil {
+		t.Errorf("update: %s", err)
+	}
+	if calls != 3 {
+		t.Errorf("got %d calls, want %d", calls, 3)
+	}
+
+	if err := db.View(ctx, fail(3)); !errors.Is(err, errTransient) {
+		t.Errorf("view: invalid error: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("got %d calls, want %d", calls, 3)
+	}
+
+	errOther := errors.New("other")
+	calls = 0
+	if err := db.Update(ctx, func(tx *bolt.Tx) error {
+		calls++
+		return errOther
+	}); err != errOther {
+		t.Errorf("update: invalid error: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("got %d calls, want %d", calls, 1)
+	}
+
+	t.Run("CancelledDuringDelay", func(t *testing.T) {
+		db := NewDB(tdb.DB, &DBOptions{
+			RetryErrors: []error{errTransient},
+			RetryDelay:  time.Hour,
+		})
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		err := db.Update(ctx, func(tx *bolt.Tx) error {
+			cancel()
+			return errTransient
+		})
+		if err != context.Canceled {
+			t.Errorf("invalid error: %v", err)
+		}
+	})
+}
+
+func TestDBBatch(t *testing.T) {
+	tdb := newTestDB(t)
+	defer tdb.Destroy()
+
+	db := NewDB(tdb.DB, &DBOptions{Batch: true})
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 50)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := db.Put(ctx, NewKeyPath([]byte(fmt.Sprintf("k%02d", i)), []byte("a")), []byte("v"), nil)
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("put: %s", err)
+		}
+	}
+	entries, err := db.List(ctx, NewPath([]byte("a")), nil)
+	if err != nil {
+		t.Fatalf("list: %s", err)
+	}
+	if len(entries) != 50 {
+		t.Errorf("got %d entries, want %d", len(entries), 50)
+	}
+}
@@ -6,15 +6,46 @@
 package boltutils // import "resenje.org/boltutils"
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 
 	bolt "go.etcd.io/bbolt"
 )
 
+// Op is the kind of the operation that returned an error.
+type Op string
+
+// Operations reported in errors.
+const (
+	OpPut          Op = "put"
+	OpDelete       Op = "delete"
+	OpCreateBucket Op = "create bucket"
+	OpDeleteBucket Op = "delete bucket"
+	OpCopyBucket   Op = "copy bucket"
+	OpExport       Op = "export"
+)
+
+var (
+	// ErrNotFound is matched by errors.Is for every NotFoundError.
+	ErrNotFound = errors.New("not found")
+	// ErrExists is matched by errors.Is for every ExistsError.
+	ErrExists = errors.New("exists")
+)
+
 // NotFoundError is returned by DeepDelete if ensure options is true.
 type NotFoundError struct {
 	Key string
+	// Segments are bucket names and the key of the path on which the
+	// operation was performed.
+	Segments [][]byte
+	// Op is the operation that returned the error.
+	Op Op
+	// Depth is the number of segments that were found before the
+	// lookup stopped.
+	Depth int
+	// Err is the underlying Bolt error, if any.
+	Err error
 }
 
 // NewNotFoundError returns a new instance of NotFoundError.
@@ -22,15 +53,32 @@ func NewNotFoundError(key string) *NotFoundError { return &NotFoundError{Key: ke
 
 func (e *NotFoundError) Error() string { return fmt.Sprintf("key not found %q", e.Key) }
 
-// IsNotFoundError returns true if provided error is of NotFoundError type.
+// Is returns true if the target is ErrNotFound.
+func (e *NotFoundError) Is(target error) bool { return target == ErrNotFound }
+
+// Unwrap returns the underlying Bolt error.
+func (e *NotFoundError) Unwrap() error { return e.Err }
+
+// IsNotFoundError returns true if provided error is or wraps an error of
+// NotFoundError type.
 func IsNotFoundError(err error) (yes bool) {
-	_, yes = err.(*NotFoundError)
-	return
+	var e *NotFoundError
+	return errors.As(err, &e)
 }
 
 // ExistsError is retuned by DeepPut if overwrite option is set to false.
 type ExistsError struct {
 	Key string
+	// Segments are bucket names and the key of the path on which the
+	// operation was performed.
+	Segments [][]byte
+	// Op is the operation that returned the error.
+	Op Op
+	// Depth is the number of segments that were found before the
+	// lookup stopped.
+	Depth int
+	// Err is the underlying Bolt error, if any.
+	Err error
 }
 
 // NewExistsError returns a new instance of ExistsError.
@@ -38,30 +86,57 @@ func NewExistsError(key string) *ExistsError { return &ExistsError{Key: key} }
 
 func (e *ExistsError) Error() string { return fmt.Sprintf("key exists %q", e.Key) }
 
-// IsExistsError returns true if provided error is of ExistsError type.
+// Is returns true if the target is ErrExists.
+func (e *ExistsError) Is(target error) bool { return target == ErrExists }
+
+// Unwrap returns the underlying Bolt error.
+func (e *ExistsError) Unwrap() error { return e.Err }
+
+// IsExistsError returns true if provided error is or wraps an error of
+// ExistsError type.
 func IsExistsError(err error) (yes bool) {
-	_, yes = err.(*ExistsError)
-	return
+	var e *ExistsError
+	return errors.As(err, &e)
+}
+
+// PathError is returned when a Bolt operation on a path fails. It wraps
+// the Bolt error, such as bolt.ErrTxNotWritable.
+type PathError struct {
+	// Op is the operation that failed.
+	Op Op
+	// Segments are bucket names and the key of the path on which the
+	// operation was performed.
+	Segments [][]byte
+	// Depth is the index of the segment on which the operation failed.
+	Depth int
+	// Err is the underlying error.
+	Err error
 }
 
+func (e *PathError) Error() string {
+	last := len(e.Segments) - 1
+	verb := "delete"
+	if e.Op == OpPut {
+		verb = "put"
+	}
+	switch {
+	case e.Op == OpCreateBucket:
+		return fmt.Sprintf("bucket create %s: %s", path(e.Segments[:e.Depth+1]...), e.Err)
+	case e.Op == OpDeleteBucket && last == 0:
+		return fmt.Sprintf("bucket %s delete: %s", e.Segments[0], e.Err)
+	case e.Op == OpPut, e.Op == OpDelete, e.Op == OpDeleteBucket:
+		return fmt.Sprintf("bucket %s %s %s: %s", path(e.Segments[:last]...), verb, e.Segments[last], e.Err)
+	}
+	return fmt.Sprintf("%s %s: %s", e.Op, path(e.Segments...), e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *PathError) Unwrap() error { return e.Err }
+
 // DeepBucket retrieves bucket named as the last element of the elements
 // arguments in nested buckets named as previous elements.
 func DeepBucket(tx *bolt.Tx, elements ...[]byte) (bucket *bolt.Bucket) {
-	length := len(elements)
-	if length < 1 {
-		return nil
-	}
-	bucket = tx.Bucket(elements[0])
-	if bucket == nil {
-		return nil
-	}
-	for i := 1; i < length; i++ {
-		bucket = bucket.Bucket(elements[i])
-		if bucket == nil {
-			return nil
-		}
-	}
-	return bucket
+	return PathBucket(tx, NewPath(elements...))
 }
 
 // DeepGet retrieves the key named as the last element of the elements
@@ -71,11 +146,7 @@ func DeepGet(tx *bolt.Tx, elements ...[]byte) (data []byte) {
 	if length < 2 {
 		return nil
 	}
-	bucket := DeepBucket(tx, elements[:length-1]...)
-	if bucket == nil {
-		return nil
-	}
-	return bucket.Get(elements[length-1])
+	return PathGet(tx, NewKeyPath(elements[length-1], elements[:length-1]...))
 }
 
 // DeepCreateBucketIfNotExists creates nested buckets with names
@@ -85,17 +156,7 @@ func DeepCreateBucketIfNotExists(tx *bolt.Tx, elements ...[]byte) (bucket *bolt.
 	if length < 1 {
 		return nil, fmt.Errorf("insufficient number of elements %d < 1", length)
 	}
-	bucket, err = tx.CreateBucketIfNotExists(elements[0])
-	if err != nil {
-		return nil, fmt.Errorf("bucket create %s: %s", elements[0], err)
-	}
-	for i := 1; i < length; i++ {
-		bucket, err = bucket.CreateBucketIfNotExists(elements[i])
-		if err != nil {
-			return nil, fmt.Errorf("bucket create %s: %s", path(elements[:i+1]...), err)
-		}
-	}
-	return bucket, nil
+	return PathCreateBucketIfNotExists(tx, NewPath(elements...))
 }
 
 // DeepPut saves the last element of elements arguments under the
@@ -109,18 +170,7 @@ func DeepPut(tx *bolt.Tx, overwrite bool, elements ...[]byte) (new bool, err err
 	if length < 3 {
 		return false, fmt.Errorf("insufficient number of elements %d < 3", length)
 	}
-	bucket, err := DeepCreateBucketIfNotExists(tx, elements[:length-2]...)
-	if err != nil {
-		return false, err
-	}
-	new = bucket.Get(elements[length-2]) == nil
-	if !overwrite && !new {
-		return false, NewExistsError(path(elements[:length-1]...))
-	}
-	if err = bucket.Put(elements[length-2], elements[length-1]); err != nil {
-		return new, fmt.Errorf("bucket %s put %s: %s", path(elements[:length-2]...), elements[length-1], err)
-	}
-	return new, nil
+	return PathPut(tx, overwrite, NewKeyPath(elements[length-2], elements[:length-2]...), elements[length-1])
 }
 
 // DeepDelete deletes the key named as the last element of the elements
@@ -132,29 +182,7 @@ func DeepDelete(tx *bolt.Tx, ensure bool, elements ...[]byte) (err error) {
 	if length < 2 {
 		return fmt.Errorf("insufficient number of elements %d < 2", length)
 	}
-	bucket := tx.Bucket(elements[0])
-	if bucket == nil {
-		if ensure {
-			return NewNotFoundError(string(elements[0]))
-		}
-		return nil
-	}
-	for i := 1; i < length-1; i++ {
-		bucket = bucket.Bucket(elements[i])
-		if bucket == nil {
-			if ensure {
-				return NewNotFoundError(path(elements[:i+1]...))
-			}
-			return nil
-		}
-	}
-	if ensure && bucket.Get(elements[length-1]) == nil {
-		return NewNotFoundError(path(elements[:length-1]...))
-	}
-	if err = bucket.Delete(elements[length-1]); err != nil {
-		return fmt.Errorf("bucket %s delete %s: %s", path(elements[:length-1]...), elements[length-1], err)
-	}
-	return
+	return PathDelete(tx, ensure, NewKeyPath(elements[length-1], elements[:length-1]...))
 }
 
 // DeepDeleteBucket deletes bucket named as the last element of the elements
@@ -166,44 +194,7 @@ func DeepDeleteBucket(tx *bolt.Tx, ensure bool, elements ...[]byte) (err error)
 	if length < 1 {
 		return fmt.Errorf("insufficient number of elements %d < 1", length)
 	}
-	bucket := tx.Bucket(elements[0])
-	if bucket == nil {
-		if ensure {
-			return NewNotFoundError(string(elements[0]))
-		}
-		return nil
-	}
-	if len(elements) == 1 {
-		if err = tx.DeleteBucket(elements[0]); err != nil {
-			if err == bolt.ErrBucketNotFound {
-				if ensure {
-					return NewNotFoundError(string(elements[0]))
-				}
-				return nil
-			}
-			return fmt.Errorf("bucket %s delete: %s", string(elements[0]), err)
-		}
-		return nil
-	}
-	for i := 1; i < length-1; i++ {
-		bucket = bucket.Bucket(elements[i])
-		if bucket == nil {
-			if ensure {
-				return NewNotFoundError(path(elements[:i+1]...))
-			}
-			return nil
-		}
-	}
-	if err = bucket.DeleteBucket(elements[length-1]); err != nil {
-		if err == bolt.ErrBucketNotFound {
-			if ensure {
-				return NewNotFoundError(path(elements[:length-1]...))
-			}
-			return nil
-		}
-		return fmt.Errorf("bucket %s delete %s: %s", path(elements[:length-1]...), elements[length-1], err)
-	}
-	return nil
+	return PathDeleteBucket(tx, ensure, NewPath(elements...))
 }
 
 // path returns comma delimited string with provided elements used in error
@@ -7,8 +7,11 @@ package boltutils
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
 	"io/ioutil"
 	"os"
+	"reflect"
 	"testing"
 
 	bolt "go.etcd.io/bbolt"
@@ -29,11 +32,11 @@ func tempfile() string {
 	return f.Name()
 }
 
-type DB struct {
+type testDB struct {
 	*bolt.DB
 }
 
-func (db DB) Destroy() {
+func (db testDB) Destroy() {
 	path := db.Path()
 	if err := db.Close(); err != nil {
 		panic(err)
@@ -43,17 +46,17 @@ func (db DB) Destroy() {
 	}
 }
 
-func NewDB(t *testing.T) DB {
+func newTestDB(t testing.TB) testDB {
 	path := tempfile()
 	db, err := bolt.Open(path, 0600, nil)
 	if err != nil {
 		panic("db open error: " + err.Error())
 	}
-	return DB{db}
+	return testDB{db}
 }
 
 func TestDeep(t *testing.T) {
-	db := NewDB(t)
+	db := newTestDB(t)
 	defer db.Destroy()
 
 	bucket1Name := []byte("bucket1")
@@ -68,7 +71,7 @@ func TestDeep(t *testing.T) {
 	putValueSecond := []byte("second")
 
 	t.Run("Put_Minimal", func(t *testing.T) {
-		db := NewDB(t)
+		db := newTestDB(t)
 		defer db.Destroy()
 
 		var new bool
@@ -688,3 +691,116 @@ func TestDeep(t *testing.T) {
 		}
 	})
 }
+
+func TestErrors(t *testing.T) {
+	db := newTestDB(t)
+	defer db.Destroy()
+
+	a, b, c, k := []byte("a"), []byte("b"), []byte("c"), []byte("k")
+
+	if err := db.DB.Update(func(tx *bolt.Tx) error {
+		_, err := DeepPut(tx, false, a, b, k, []byte("v"))
+		return err
+	}); err != nil {
+		t.Fatalf("bolt db update transaction %s", err)
+	}
+
+	t.Run("NotFound", func(t *testing.T) {
+		err := db.DB.Update(func(tx *bolt.Tx) error {
+			return DeepDelete(tx, true, a, c, k)
+		})
+		if !errors.Is(err, ErrNotFound) || errors.Is(err, ErrExists) {
+			t.Errorf("invalid error: %v", err)
+		}
+		if !IsNotFoundError(fmt.Errorf("wrapped: %w", err)) {
+			t.Error("wrapped error is not NotFoundError")
+		}
+		var e *NotFoundError
+		if !errors.As(err, &e) {
+			t.Fatalf("invalid error: %v", err)
+		}
+		if msg := e.Error(); msg != `key not found "a, c"` {
+			t.Errorf("got error message %q", msg)
+		}
+		if e.Op != OpDelete || e.Depth != 1 || !reflect.DeepEqual(e.Segments, [][]byte{a, c, k}) {
+			t.Errorf("got op %q, depth %d, segments %q", e.Op, e.Depth, e.Segments)
+		}
+
+		err = db.DB.Update(func(tx *bolt.Tx) error {
+			return DeepDeleteBucket(tx, true, a, b, c)
+		})
+		if !errors.As(err, &e) {
+			t.Fatalf("invalid error: %v", err)
+		}
+		if e.Op != OpDeleteBucket || e.Depth != 2 || !errors.Is(err, bolt.ErrBucketNotFound) {
+			t.Errorf("got op %q, depth %d, error %v", e.Op, e.Depth, e.Err)
+		}
+	})
+
+	t.Run("Exists", func(t *testing.T) {
+		err := db.DB.Update(func(tx *bolt.Tx) error {
+			_, err := DeepPut(tx, false, a, b, k, []byte("v"))
+			return err
+		})
+		if !errors.Is(err, ErrExists) || errors.Is(err, ErrNotFound) {
+			t.Errorf("invalid error: %v", err)
+		}
+		var e *ExistsError
+		if !errors.As(err, &e) {
+			t.Fatalf("invalid error: %v", err)
+		}
+		if msg := e.Error(); msg != `key exists "a, b, k"` {
+			t.Errorf("got error message %q", msg)
+		}
+		if e.Op != OpPut || e.Depth != 3 || !reflect.DeepEqual(e.Segments, [][]byte{a, b, k}) {
+			t.Errorf("got op %q, depth %d, segments %q", e.Op, e.Depth, e.Segments)
+		}
+	})
+
+	t.Run("Bolt", func(t *testing.T) {
+		err := db.DB.Update(func(tx *bolt.Tx) error {
+			_, err := DeepPut(tx, true, a, b, []byte{}, []byte("v"))
+			return err
+		})
+		if !errors.Is(err, bolt.ErrKeyRequired) {
+			t.Errorf("invalid error: %v", err)
+		}
+		var e *PathError
+		if !errors.As(err, &e) {
+			t.Fatalf("invalid error: %v", err)
+		}
+		if msg := e.Error(); msg != "bucket a, b put : key required" {
+			t.Errorf("got error message %q", msg)
+		}
+		if e.Op != OpPut || e.Depth != 2 {
+			t.Errorf("got op %q, depth %d", e.Op, e.Depth)
+		}
+
+		if err := db.DB.View(func(tx *bolt.Tx) error {
+			_, err := DeepCreateBucketIfNotExists(tx, a, c)
+			if !errors.As(err, &e) || !errors.Is(err, bolt.ErrTxNotWritable) {
+				t.Fatalf("invalid error: %v", err)
+			}
+			if msg := e.Error(); msg != "bucket create a: tx not writable" {
+				t.Errorf("got error message %q", msg)
+			}
+			if e.Op != OpCreateBucket || e.Depth != 0 {
+				t.Errorf("got op %q, depth %d", e.Op, e.Depth)
+			}
+
+			err = DeepDeleteBucket(tx, true, a, b)
+			if !errors.As(err, &e) || !errors.Is(err, bolt.ErrTxNotWritable) {
+				t.Fatalf("invalid error: %v", err)
+			}
+			if msg := e.Error(); msg != "bucket a delete b: tx not writable" {
+				t.Errorf("got error message %q", msg)
+			}
+			if e.Op != OpDeleteBucket || e.Depth != 1 {
+				t.Errorf("got op %q, depth %d", e.Op, e.Depth)
+			}
+			return nil
+		}); err != nil {
+			t.Fatalf("bolt db view transaction %s", err)
+		}
+	})
+}
@@ -0,0 +1,310 @@
+// Copyright (c) 2026, Janoš Guljaš <janos@resenje.org>
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package boltutils
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Encoding specifies how bucket names, keys and values are represented
+// in exported JSON documents.
+type Encoding int
+
+// Supported binary data encodings.
+const (
+	// EncodingBase64 represents data as standard base64 strings.
+	EncodingBase64 Encoding = iota
+	// EncodingHex represents data as hexadecimal strings.
+	EncodingHex
+)
+
+func (e Encoding) encode(data []byte) string {
+	if e == EncodingHex {
+		return hex.EncodeToString(data)
+	}
+	return base64.StdEncoding.EncodeToString(data)
+}
+
+func (e Encoding) decode(s string) (data []byte, err error) {
+	if e == EncodingHex {
+		return hex.DecodeString(s)
+	}
+	return base64.StdEncoding.DecodeString(s)
+}
+
+// Format specifies the structure of exported data.
+type Format struct {
+	// NDJSON selects newline-delimited JSON with one object per key or
+	// empty bucket. Otherwise, a single JSON document with nested
+	// buckets is used.
+	NDJSON bool
+	// Encoding of bucket names, keys and values.
+	Encoding Encoding
+}
+
+// exportBucket is a bucket in a JSON document.
+type exportBucket struct {
+	Name    string         `json:"name,omitempty"`
+	Keys    []exportKey    `json:"keys,omitempty"`
+	Buckets []exportBucket `json:"buckets,omitempty"`
+}
+
+// exportKey is a key with its value in a JSON document.
+type exportKey struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// exportLine is a single line in an NDJSON document. Path holds names
+// of buckets relative to the exported bucket. If Key is nil, the line
+// represents an empty bucket.
+type exportLine struct {
+	Path  []string `json:"path"`
+	Key   *string  `json:"key,omitempty"`
+	Value *string  `json:"value,omitempty"`
+}
+
+// Export writes all keys and buckets nested in the bucket referenced by
+// the path to the writer in the provided format. If the path has no
+// buckets, all top level buckets are exported, except the meta bucket.
+// Expired keys are not exported.
+func Export(tx *bolt.Tx, w io.Writer, p Path, format Format) (err error) {
+	if depth := bucketDepth(tx, p.Buckets); depth < len(p.Buckets) {
+		return &NotFoundError{Key: path(p.Buckets...), Segments: p.Buckets, Op: OpExport, Depth: depth}
+	}
+	if format.NDJSON {
+		return exportNDJSON(tx, w, p, format.Encoding)
+	}
+	root := &exportBucket{}
+	// stack holds the exported bucket and all buckets that contain the
+	// bucket that is currently visited.
+	stack := []*exportBucket{root}
+	base := len(p.Buckets)
+	if err := DeepForEach(tx, p.Bucket(), func(e Path, value []byte) error {
+		depth := len(e.Buckets) - base
+		if !e.HasKey() {
+			stack = stack[:depth]
+			parent := stack[depth-1]
+			parent.Buckets = append(parent.Buckets, exportBucket{
+				Name: format.Encoding.encode(e.Buckets[len(e.Buckets)-1]),
+			})
+			stack = append(stack, &parent.Buckets[len(parent.Buckets)-1])
+			return nil
+		}
+		stack = stack[:depth+1]
+		b := stack[depth]
+		b.Keys = append(b.Keys, exportKey{
+			Key:   format.Encoding.encode(e.Key),
+			Value: format.Encoding.encode(value),
+		})
+		return nil
+	}); err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(root); err != nil {
+		return fmt.Errorf("export encode: %w", err)
+	}
+	return nil
+}
+
+func exportNDJSON(tx *bolt.Tx, w io.Writer, p Path, e Encoding) (err error) {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	base := len(p.Buckets)
+	// pending is the last visited bucket, which is written only if it
+	// is empty. That is known when the next element is not in it.
+	var pending *exportLine
+	var pendingPath Path
+	flush := func() error {
+		if pending == nil {
+			return nil
+		}
+		l := pending
+		pending = nil
+		return enc.Encode(l)
+	}
+	if err := DeepForEach(tx, p.Bucket(), func(el Path, value []byte) error {
+		if pending != nil && el.HasPrefix(pendingPath) {
+			pending = nil
+		}
+		if err := flush(); err != nil {
+			return err
+		}
+		names := make([]string, 0, len(el.Buckets)-base)
+		for _, b := range el.Buckets[base:] {
+			names = append(names, e.encode(b))
+		}
+		if !el.HasKey() {
+			pending = &exportLine{Path: names}
+			pendingPath = el
+			return nil
+		}
+		k, v := e.encode(el.Key), e.encode(value)
+		return enc.Encode(exportLine{Path: names, Key: &k, Value: &v})
+	}); err != nil {
+		return fmt.Errorf("export encode: %w", err)
+	}
+	if err := flush(); err != nil {
+		return fmt.Errorf("export encode: %w", err)
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("export write: %w", err)
+	}
+	return nil
+}
+
+// Conflict specifies how Import handles keys that already exist.
+type Conflict int
+
+// Conflict handling policies.
+const (
+	// ConflictFail aborts the import with ExistsError.
+	ConflictFail Conflict = iota
+	// ConflictOverwrite replaces existing values with imported ones.
+	ConflictOverwrite
+	// ConflictMerge keeps existing values and imports only keys that do
+	// not exist.
+	ConflictMerge
+)
+
+// ImportOptions holds optional parameters for Import.
+type ImportOptions struct {
+	// Format of the imported data.
+	Format Format
+	// Conflict handling policy for existing keys.
+	Conflict Conflict
+}
+
+// Import reads keys and buckets in the format written by Export and
+// saves them in the bucket referenced by the path, creating all buckets
+// that do not exist. If the path has no buckets, imported data must not
+// contain keys that are not in buckets.
+func Import(tx *bolt.Tx, r io.Reader, p Path, opts *ImportOptions) (err error) {
+	if opts == nil {
+		opts = new(ImportOptions)
+	}
+	imp := importer{
+		tx:       tx,
+		encoding: opts.Format.Encoding,
+		conflict: opts.Conflict,
+	}
+	if opts.Format.NDJSON {
+		return imp.ndjson(r, p.Bucket())
+	}
+	var root exportBucket
+	if err := json.NewDecoder(r).Decode(&root); err != nil {
+		return fmt.Errorf("import decode: %w", err)
+	}
+	return imp.bucket(p.Bucket(), root)
+}
+
+type importer struct {
+	tx       *bolt.Tx
+	encoding Encoding
+	conflict Conflict
+}
+
+func (imp importer) bucket(p Path, b exportBucket) (err error) {
+	if len(b.Keys) > 0 && len(p.Buckets) == 0 {
+		return errors.New("import keys without bucket")
+	}
+	if len(p.Buckets) > 0 {
+		if _, err := DeepCreateBucketIfNotExists(imp.tx, p.Buckets...); err != nil {
+			return err
+		}
+	}
+	for _, k := range b.Keys {
+		key, err := imp.encoding.decode(k.Key)
+		if err != nil {
+			return fmt.Errorf("import bucket %s key %q: %w", path(p.Buckets...), k.Key, err)
+		}
+		value, err := imp.encoding.decode(k.Value)
+		if err != nil {
+			return fmt.Errorf("import bucket %s key %q value: %w", path(p.Buckets...), k.Key, err)
+		}
+		if err := imp.put(p.WithKey(key), value); err != nil {
+			return err
+		}
+	}
+	for _, c := range b.Buckets {
+		name, err := imp.encoding.decode(c.Name)
+		if err != nil {
+			return fmt.Errorf("import bucket %s name %q: %w", path(p.Buckets...), c.Name, err)
+		}
+		if err := imp.bucket(p.Child(name), c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (imp importer) ndjson(r io.Reader, p Path) (err error) {
+	dec := json.NewDecoder(r)
+	for line := 1; ; line++ {
+		var l exportLine
+		if err := dec.Decode(&l); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("import decode line %d: %w", line, err)
+		}
+		e := p
+		for _, n := range l.Path {
+			name, err := imp.encoding.decode(n)
+			if err != nil {
+				return fmt.Errorf("import line %d bucket name %q: %w", line, n, err)
+			}
+			e = e.Child(name)
+		}
+		if len(e.Buckets) == 0 {
+			return fmt.Errorf("import line %d: key without bucket", line)
+		}
+		if l.Key == nil {
+			if _, err := DeepCreateBucketIfNotExists(imp.tx, e.Buckets...); err != nil {
+				return err
+			}
+			continue
+		}
+		key, err := imp.encoding.decode(*l.Key)
+		if err != nil {
+			return fmt.Errorf("import line %d key %q: %w", line, *l.Key, err)
+		}
+		var value []byte
+		if l.Value != nil {
+			value, err = imp.encoding.decode(*l.Value)
+			if err != nil {
+				return fmt.Errorf("import line %d key %q value: %w", line, *l.Key, err)
+			}
+		}
+		if _, err := DeepCreateBucketIfNotExists(imp.tx, e.Buckets...); err != nil {
+			return err
+		}
+		if err := imp.put(e.WithKey(key), value); err != nil {
+			return err
+		}
+	}
+}
+
+func (imp importer) put(p Path, value []byte) (err error) {
+	if value == nil {
+		value = []byte{}
+	}
+	_, err = PathPut(imp.tx, imp.conflict == ConflictOverwrite, p, value)
+	if imp.conflict == ConflictMerge && IsExistsError(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,237 @@
+// Copyright (c) 2026, Janoš Guljaš <janos@resenje.org>
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package boltutils
+
+import (
+	"bytes"
+	"encoding/hex"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// dump returns all keys and buckets nested in the bucket referenced by
+// the path as strings formatted with Path.String.
+func dump(t *testing.T, db testDB, p Path) (got []string) {
+	t.Helper()
+
+	if err := db.DB.View(func(tx *bolt.Tx) error {
+		return DeepForEach(tx, p, func(p Path, value []byte) error {
+			if p.HasKey() {
+				got = append(got, p.String()+"="+string(value))
+			} else {
+				got = append(got, p.String()+"/")
+			}
+			return nil
+		})
+	}); err != nil {
+		t.Fatalf("bolt db view transaction %s", err)
+	}
+	return got
+}
+
+func TestExportImport(t *testing.T) {
+	db := newTestDB(t)
+	defer db.Destroy()
+
+	if err := db.DB.Update(func(tx *bolt.Tx) error {
+		for _, elements := range [][]string{
+			{"src", "k1", "v1"},
+			{"src", "a", "k2", "v2"},
+			{"src", "a", "b", "k3", "\x00\xff"},
+			{"src", "a", "k4", "v4"},
+		} {
+			e := make([][]byte, 0, len(elements))
+			for _, s := range elements {
+				e = append(e, []byte(s))
+			}
+			if _, err := DeepPut(tx, false, e...); err != nil {
+				return err
+			}
+		}
+		if _, err := PathPutWithExpiry(tx, false, NewKeyPath([]byte("expired"), []byte("src"), []byte("a")), []byte("v5"), time.Now().Add(-time.Hour)); err != nil {
+			return err
+		}
+		_, err := DeepCreateBucketIfNotExists(tx, []byte("src"), []byte("a"), []byte("empty"))
+		return err
+	}); err != nil {
+		t.Fatalf("bolt db update transaction %s", err)
+	}
+
+	want := []string{
+		"dst/a/",
+		"dst/a/b/",
+		`dst/a/b/k3=` + "\x00\xff",
+		"dst/a/empty/",
+		"dst/a/k2=v2",
+		"dst/a/k4=v4",
+		"dst/k1=v1",
+	}
+
+	for _, format := range []Format{
+		{},
+		{Encoding: EncodingHex},
+		{NDJSON: true},
+		{NDJSON: true, Encoding: EncodingHex},
+	} {
+		var buf bytes.Buffer
+		if err := db.DB.View(func(tx *bolt.Tx) error {
+			return Export(tx, &buf, NewPath([]byte("src")), format)
+		}); err != nil {
+			t.Fatalf("format %+v: export: %s", format, err)
+		}
+		data := buf.String()
+		if strings.Contains(data, "expired") || strings.Contains(data, hex.EncodeToString([]byte("expired"))) {
+			t.Errorf("format %+v: expired key exported", format)
+		}
+
+		if err := db.DB.Update(func(tx *bolt.Tx) error {
+			return Import(tx, strings.NewReader(data), NewPath([]byte("dst")), &ImportOptions{Format: format})
+		}); err != nil {
+			t.Fatalf("format %+v: import: %s", format, err)
+		}
+		if err := db.DB.View(func(tx *bolt.Tx) error {
+			if v := DeepBucket(tx, []byte("dst"), []byte("a")).Get([]byte("expired")); v != nil {
+				t.Errorf("format %+v: got expired key value %q", format, v)
+			}
+			return nil
+		}); err != nil {
+			t.Fatalf("bolt db view transaction %s", err)
+		}
+		if got := dump(t, db, NewPath([]byte("dst"))); !reflect.DeepEqual(got, want) {
+			t.Errorf("format %+v: got %q, want %q", format, got, want)
+		}
+
+		// Import of the same data fails on the first existing key.
+		err := db.DB.Update(func(tx *bolt.Tx) error {
+			return Import(tx, strings.NewReader(data), NewPath([]byte("dst")), &ImportOptions{Format: format})
+		})
+		if !IsExistsError(err) {
+			t.Errorf("format %+v: invalid error: %v", format, err)
+		}
+
+		if err := db.DB.Update(func(tx *bolt.Tx) error {
+			return DeepDeleteBucket(tx, true, []byte("dst"))
+		}); err != nil {
+			t.Fatalf("bolt db update transaction %s", err)
+		}
+	}
+
+	t.Run("Conflict", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := db.DB.View(func(tx *bolt.Tx) error {
+			return Export(tx, &buf, NewPath([]byte("src"), []byte("a")), Format{NDJSON: true})
+		}); err != nil {
+			t.Fatalf("export: %s", err)
+		}
+		data := buf.String()
+
+		dst := NewPath([]byte("dst"))
+		put := func() {
+			if err := db.DB.Update(func(tx *bolt.Tx) error {
+				if _, err := PathPut(tx, true, dst.WithKey([]byte("k2")), []byte("existing")); err != nil {
+					return err
+				}
+				_, err := PathPut(tx, true, dst.WithKey([]byte("k5")), []byte("v5"))
+				return err
+			}); err != nil {
+				t.Fatalf("bolt db update transaction %s", err)
+			}
+		}
+
+		put()
+		err := db.DB.Update(func(tx *bolt.Tx) error {
+			return Import(tx, strings.NewReader(data), dst, &ImportOptions{Format: Format{NDJSON: true}})
+		})
+		if !IsExistsError(err) {
+			t.Fatalf("invalid error: %v", err)
+		}
+		if msg := err.Error(); msg != `key exists "dst, k2"` {
+			t.Errorf("got error %q", msg)
+		}
+
+		if err := db.DB.Update(func(tx *bolt.Tx) error {
+			return Import(tx, strings.NewReader(data), dst, &ImportOptions{Format: Format{NDJSON: true}, Conflict: ConflictMerge})
+		}); err != nil {
+			t.Fatalf("import: %s", err)
+		}
+		want := []string{
+			"dst/b/",
+			"dst/b/k3=\x00\xff",
+			"dst/empty/",
+			"dst/k2=existing",
+			"dst/k4=v4",
+			"dst/k5=v5",
+		}
+		if got := dump(t, db, dst); !reflect.DeepEqual(got, want) {
+			t.Errorf("merge: got %q, want %q", got, want)
+		}
+
+		if err := db.DB.Update(func(tx *bolt.Tx) error {
+			return Import(tx, strings.NewReader(data), dst, &ImportOptions{Format: Format{NDJSON: true}, Conflict: ConflictOverwrite})
+		}); err != nil {
+			t.Fatalf("import: %s", err)
+		}
+		want[3] = "dst/k2=v2"
+		if got := dump(t, db, dst); !reflect.DeepEqual(got, want) {
+			t.Errorf("overwrite: got %q, want %q", got, want)
+		}
+	})
+
+	t.Run("MetaBucket", func(t *testing.T) {
+		if err := db.DB.Update(func(tx *bolt.Tx) error {
+			_, err := PathPutWithExpiry(tx, true, NewKeyPath([]byte("ttl"), []byte("src")), []byte("v"), time.Now().Add(time.Hour))
+			return err
+		}); err != nil {
+			t.Fatalf("bolt db update transaction %s", err)
+		}
+		for _, format := range []Format{{}, {NDJSON: true}} {
+			var buf bytes.Buffer
+			if err := db.DB.View(func(tx *bolt.Tx) error {
+				return Export(tx, &buf, Path{}, format)
+			}); err != nil {
+				t.Fatalf("export: %s", err)
+			}
+			target := newTestDB(t)
+			defer target.Destroy()
+			if err := target.DB.Update(func(tx *bolt.Tx) error {
+				return Import(tx, &buf, Path{}, &ImportOptions{Format: format})
+			}); err != nil {
+				t.Fatalf("import: %s", err)
+			}
+			if err := target.DB.View(func(tx *bolt.Tx) error {
+				if tx.Bucket(MetaBucketName) != nil {
+					t.Errorf("%+v: meta bucket exported", format)
+				}
+				return nil
+			}); err != nil {
+				t.Fatalf("bolt db view transaction %s", err)
+			}
+			if got, want := dump(t, target, NewPath([]byte("src"))), dump(t, db, NewPath([]byte("src"))); !reflect.DeepEqual(got, want) {
+				t.Errorf("%+v: got %q, want %q", format, got, want)
+			}
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		if err := db.DB.View(func(tx *bolt.Tx) error {
+			return Export(tx, new(bytes.Buffer), NewPath([]byte("missing")), Format{})
+		}); !IsNotFoundError(err) {
+			t.Errorf("invalid error: %v", err)
+		}
+	})
+
+	t.Run("KeysWithoutBucket", func(t *testing.T) {
+		if err := db.DB.Update(func(tx *bolt.Tx) error {
+			return Import(tx, strings.NewReader(`{"keys":[{"key":"aw==","value":"dg=="}]}`), Path{}, nil)
+		}); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
@@ -1,8 +1,10 @@
 module resenje.org/boltutils
 
-go 1.13
+go 1.18
 
 require (
 	go.etcd.io/bbolt v1.3.7
-	golang.org/x/sys v0.6.0 // indirect
+	golang.org/x/text v0.14.0
 )
+
+require golang.org/x/sys v0.6.0 // indirect
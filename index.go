@@ -0,0 +1,329 @@
+// Copyright (c) 2026, Janoš Guljaš <janos@resenje.org>
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package boltutils
+
+import (
+	"errors"
+	"fmt"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// IndexFunc returns index keys for the value stored under the path.
+// Returned keys must not be empty.
+type IndexFunc func(p Path, value []byte) (keys [][]byte, err error)
+
+// Index defines a secondary index over keys stored in a bucket.
+type Index struct {
+	// Name is the unique name of the index in the IndexRegistry.
+	Name string
+	// Source references the bucket with indexed keys. All keys in this
+	// bucket and all its nested buckets are indexed.
+	Source Path
+	// Bucket references the bucket in which index entries are stored.
+	// It must not contain or be nested in the Source bucket.
+	Bucket Path
+	// Func returns index keys for every indexed value.
+	Func IndexFunc
+}
+
+// IndexProblem describes a difference between the stored index entries
+// and the ones that are expected from the indexed data.
+type IndexProblem struct {
+	// Key is the index key.
+	Key []byte
+	// Path references the indexed key.
+	Path Path
+	// Missing is true if the entry is expected but not stored in the
+	// index, and false if the entry is stored in the index but it is
+	// not expected.
+	Missing bool
+}
+
+func (p IndexProblem) String() string {
+	if p.Missing {
+		return fmt.Sprintf("missing index key %q for %s", p.Key, p.Path)
+	}
+	return fmt.Sprintf("stale index key %q for %s", p.Key, p.Path)
+}
+
+// IndexRegistry keeps secondary indexes in sync with the data when keys
+// are put or deleted through its methods. Index entries are updated in
+// the same transaction as the data.
+type IndexRegistry struct {
+	indexes []Index
+}
+
+// NewIndexRegistry returns a new IndexRegistry without indexes.
+func NewIndexRegistry() (r *IndexRegistry) {
+	return new(IndexRegistry)
+}
+
+// Register adds the index to the registry. Existing data is not indexed
+// until Reindex is called.
+func (r *IndexRegistry) Register(i Index) (err error) {
+	if i.Name == "" {
+		return errors.New("index name is required")
+	}
+	if i.Func == nil {
+		return fmt.Errorf("index %s: function is required", i.Name)
+	}
+	if len(i.Source.Buckets) < 1 {
+		return fmt.Errorf("index %s: insufficient number of source buckets %d < 1", i.Name, len(i.Source.Buckets))
+	}
+	if len(i.Bucket.Buckets) < 1 {
+		return fmt.Errorf("index %s: insufficient number of index buckets %d < 1", i.Name, len(i.Bucket.Buckets))
+	}
+	if i.Bucket.HasPrefix(i.Source) || i.Source.HasPrefix(i.Bucket) {
+		return fmt.Errorf("index %s: bucket %s overlaps source bucket %s", i.Name, path(i.Bucket.Buckets...), path(i.Source.Buckets...))
+	}
+	if _, ok := r.index(i.Name); ok {
+		return fmt.Errorf("index %s already registered", i.Name)
+	}
+	i.Source = i.Source.Bucket()
+	i.Bucket = i.Bucket.Bucket()
+	r.indexes = append(r.indexes, i)
+	return nil
+}
+
+func (r *IndexRegistry) index(name string) (i Index, ok bool) {
+	for _, i := range r.indexes {
+		if i.Name == name {
+			return i, true
+		}
+	}
+	return Index{}, false
+}
+
+// Put saves the value in the same way as PathPut does, and updates
+// entries of all indexes with the source bucket that contains the key.
+func (r *IndexRegistry) Put(tx *bolt.Tx, overwrite bool, p Path, value []byte) (new bool, err error) {
+	old := rawGet(tx, p)
+	new, err = PathPut(tx, overwrite, p, value)
+	if err != nil {
+		return new, err
+	}
+	for _, i := range r.indexes {
+		if !p.HasPrefix(i.Source) {
+			continue
+		}
+		if old != nil {
+			if err := i.remove(tx, p, old); err != nil {
+				return new, err
+			}
+		}
+		if err := i.add(tx, p, value); err != nil {
+			return new, err
+		}
+	}
+	return new, nil
+}
+
+// Delete deletes the key in the same way as PathDelete does, and
+// removes its entries from all indexes with the source bucket that
+// contains the key.
+func (r *IndexRegistry) Delete(tx *bolt.Tx, ensure bool, p Path) (err error) {
+	old := rawGet(tx, p)
+	if err := PathDelete(tx, ensure, p); err != nil {
+		return err
+	}
+	if old == nil {
+		return nil
+	}
+	for _, i := range r.indexes {
+		if !p.HasPrefix(i.Source) {
+			continue
+		}
+		if err := i.remove(tx, p, old); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Lookup returns paths of all keys that have the provided index key.
+// Returned paths are valid only for the life of the transaction.
+func (r *IndexRegistry) Lookup(tx *bolt.Tx, name string, key []byte) (paths []Path, err error) {
+	i, ok := r.index(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown index %s", name)
+	}
+	bucket := PathBucket(tx, i.Bucket.Child(key))
+	if bucket == nil {
+		return nil, nil
+	}
+	if err := bucket.ForEach(func(k, _ []byte) error {
+		var p Path
+		if err := p.UnmarshalBinary(k); err != nil {
+			return fmt.Errorf("index %s key %q: %w", name, key, err)
+		}
+		paths = append(paths, p)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
+
+// Reindex removes all entries from the index and adds entries for all
+// keys in its source bucket. It returns the number of indexed keys.
+func (r *IndexRegistry) Reindex(tx *bolt.Tx, name string) (n int, err error) {
+	i, ok := r.index(name)
+	if !ok {
+		return 0, fmt.Errorf("unknown index %s", name)
+	}
+	if err := PathDeleteBucket(tx, false, i.Bucket); err != nil {
+		return 0, err
+	}
+	if _, err := PathCreateBucketIfNotExists(tx, i.Bucket); err != nil {
+		return 0, err
+	}
+	if err := DeepForEach(tx, i.Source, func(p Path, value []byte) error {
+		if !p.HasKey() {
+			return nil
+		}
+		if err := i.add(tx, p, value); err != nil {
+			return err
+		}
+		n++
+		return nil
+	}); err != nil {
+		return n, err
+	}
+	return n, nil
+}
+
+// Verify compares entries stored in the index with the ones expected
+// from keys in its source bucket and returns all differences.
+func (r *IndexRegistry) Verify(tx *bolt.Tx, name string) (problems []IndexProblem, err error) {
+	i, ok := r.index(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown index %s", name)
+	}
+	expected := make(map[string]struct{})
+	if err := DeepForEach(tx, i.Source, func(p Path, value []byte) error {
+		if !p.HasKey() {
+			return nil
+		}
+		keys, err := i.keys(p, value)
+		if err != nil {
+			return err
+		}
+		for _, k := range keys {
+			entry := indexEntry(k, p.appendBinary(nil))
+			if _, ok := expected[entry]; ok {
+				continue
+			}
+			expected[entry] = struct{}{}
+			if b := PathBucket(tx, i.Bucket.Child(k)); b == nil || b.Get(p.appendBinary(nil)) == nil {
+				problems = append(problems, IndexProblem{Key: k, Path: p, Missing: true})
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	bucket := PathBucket(tx, i.Bucket)
+	if bucket == nil {
+		return problems, nil
+	}
+	if err := bucket.ForEach(func(k, v []byte) error {
+		if v != nil {
+			return nil
+		}
+		return bucket.Bucket(k).ForEach(func(pk, _ []byte) error {
+			if _, ok := expected[indexEntry(k, pk)]; ok {
+				return nil
+			}
+			var p Path
+			if err := p.UnmarshalBinary(pk); err != nil {
+				return fmt.Errorf("index %s key %q: %w", name, k, err)
+			}
+			problems = append(problems, IndexProblem{Key: k, Path: p})
+			return nil
+		})
+	}); err != nil {
+		return nil, err
+	}
+	return problems, nil
+}
+
+func (i Index) keys(p Path, value []byte) (keys [][]byte, err error) {
+	keys, err = i.Func(p, value)
+	if err != nil {
+		return nil, fmt.Errorf("index %s %s: %w", i.Name, p, err)
+	}
+	for _, k := range keys {
+		if len(k) == 0 {
+			return nil, fmt.Errorf("index %s %s: empty index key", i.Name, p)
+		}
+	}
+	return keys, nil
+}
+
+func (i Index) add(tx *bolt.Tx, p Path, value []byte) (err error) {
+	keys, err := i.keys(p, value)
+	if err != nil {
+		return err
+	}
+	for _, k := range keys {
+		bucket, err := PathCreateBucketIfNotExists(tx, i.Bucket.Child(k))
+		if err != nil {
+			return err
+		}
+		if err := bucket.Put(p.appendBinary(nil), []byte{}); err != nil {
+			return fmt.Errorf("index %s put %q %s: %w", i.Name, k, p, err)
+		}
+	}
+	return nil
+}
+
+func (i Index) remove(tx *bolt.Tx, p Path, value []byte) (err error) {
+	keys, err := i.keys(p, value)
+	if err != nil {
+		return err
+	}
+	parent := PathBucket(tx, i.Bucket)
+	if parent == nil {
+		return nil
+	}
+	for _, k := range keys {
+		bucket := parent.Bucket(k)
+		if bucket == nil {
+			continue
+		}
+		if err := bucket.Delete(p.appendBinary(nil)); err != nil {
+			return fmt.Errorf("index %s delete %q %s: %w", i.Name, k, p, err)
+		}
+		if first, _ := bucket.Cursor().First(); first != nil {
+			continue
+		}
+		if err := parent.DeleteBucket(k); err != nil {
+			return fmt.Errorf("index %s delete %q: %w", i.Name, k, err)
+		}
+	}
+	return nil
+}
+
+// rawGet returns a copy of the value stored under the path, regardless
+// of its expiration time.
+func rawGet(tx *bolt.Tx, p Path) (value []byte) {
+	bucket := PathBucket(tx, p)
+	if bucket == nil {
+		return nil
+	}
+	v := bucket.Get(p.Key)
+	if v == nil {
+		return nil
+	}
+	return append([]byte{}, v...)
+}
+
+// indexEntry returns an unambiguous string representation of the index
+// key and the binary encoded path.
+func indexEntry(key, p []byte) string {
+	return string(appendUvarint(nil, uint64(len(key)))) + string(key) + string(p)
+}
@@ -0,0 +1,170 @@
+// Copyright (c) 2026, Janoš Guljaš <janos@resenje.org>
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package boltutils
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+	"testing"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestIndexRegistry(t *testing.T) {
+	db := newTestDB(t)
+	defer db.Destroy()
+
+	users := NewPath([]byte("users"))
+	byColor := Index{
+		Name:   "color",
+		Source: users,
+		Bucket: NewPath([]byte("indexes"), []byte("color")),
+		// Values are comma separated colors.
+		Func: func(p Path, value []byte) (keys [][]byte, err error) {
+			if len(value) == 0 {
+				return nil, nil
+			}
+			return bytes.Split(value, []byte(",")), nil
+		},
+	}
+
+	r := NewIndexRegistry()
+	if err := r.Register(byColor); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Register(byColor); err == nil {
+		t.Error("expected error for duplicate index")
+	}
+	if err := r.Register(Index{Name: "overlap", Source: users, Bucket: users.Child([]byte("index")), Func: byColor.Func}); err == nil {
+		t.Error("expected error for overlapping index bucket")
+	}
+
+	lookup := func(tx *bolt.Tx, color string) (got []string) {
+		paths, err := r.Lookup(tx, "color", []byte(color))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, p := range paths {
+			got = append(got, p.String())
+		}
+		sort.Strings(got)
+		return got
+	}
+
+	alice := users.WithKey([]byte("alice"))
+	bob := users.Child([]byte("staff")).WithKey([]byte("bob"))
+
+	if err := db.DB.Update(func(tx *bolt.Tx) error {
+		if _, err := r.Put(tx, false, alice, []byte("red,blue")); err != nil {
+			return err
+		}
+		if _, err := r.Put(tx, false, bob, []byte("blue")); err != nil {
+			return err
+		}
+		// Keys outside of the source bucket are not indexed.
+		_, err := r.Put(tx, false, NewKeyPath([]byte("carol"), []byte("guests")), []byte("red"))
+		return err
+	}); err != nil {
+		t.Fatalf("bolt db update transaction %s", err)
+	}
+
+	if err := db.DB.View(func(tx *bolt.Tx) error {
+		if got, want := lookup(tx, "red"), []string{"users/alice"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("red: got %q, want %q", got, want)
+		}
+		if got, want := lookup(tx, "blue"), []string{"users/alice", "users/staff/bob"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("blue: got %q, want %q", got, want)
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("bolt db view transaction %s", err)
+	}
+
+	if err := db.DB.Update(func(tx *bolt.Tx) error {
+		if _, err := r.Put(tx, true, alice, []byte("green")); err != nil {
+			return err
+		}
+		return r.Delete(tx, true, bob)
+	}); err != nil {
+		t.Fatalf("bolt db update transaction %s", err)
+	}
+
+	if err := db.DB.View(func(tx *bolt.Tx) error {
+		if got := lookup(tx, "red"); got != nil {
+			t.Errorf("red: got %q, want none", got)
+		}
+		if got := lookup(tx, "blue"); got != nil {
+			t.Errorf("blue: got %q, want none", got)
+		}
+		if got, want := lookup(tx, "green"), []string{"users/alice"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("green: got %q, want %q", got, want)
+		}
+		if b := PathBucket(tx, byColor.Bucket.Child([]byte("red"))); b != nil {
+			t.Error("empty index key bucket is not deleted")
+		}
+		problems, err := r.Verify(tx, "color")
+		if err != nil {
+			return err
+		}
+		if len(problems) != 0 {
+			t.Errorf("unexpected problems %v", problems)
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("bolt db view transaction %s", err)
+	}
+
+	t.Run("Reindex", func(t *testing.T) {
+		if err := db.DB.Update(func(tx *bolt.Tx) error {
+			// Data changed without the registry.
+			if _, err := PathPut(tx, false, users.WithKey([]byte("dave")), []byte("red")); err != nil {
+				return err
+			}
+			if _, err := PathPut(tx, true, alice, []byte("blue")); err != nil {
+				return err
+			}
+			problems, err := r.Verify(tx, "color")
+			if err != nil {
+				return err
+			}
+			var got []string
+			for _, p := range problems {
+				got = append(got, p.String())
+			}
+			sort.Strings(got)
+			want := []string{
+				`missing index key "blue" for users/alice`,
+				`missing index key "red" for users/dave`,
+				`stale index key "green" for users/alice`,
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got problems %q, want %q", got, want)
+			}
+
+			n, err := r.Reindex(tx, "color")
+			if err != nil {
+				return err
+			}
+			if n != 2 {
+				t.Errorf("reindexed %d keys, want %d", n, 2)
+			}
+			problems, err = r.Verify(tx, "color")
+			if err != nil {
+				return err
+			}
+			if len(problems) != 0 {
+				t.Errorf("unexpected problems %v", problems)
+			}
+			if got, want := lookup(tx, "red"), []string{"users/dave"}; !reflect.DeepEqual(got, want) {
+				t.Errorf("red: got %q, want %q", got, want)
+			}
+			return nil
+		}); err != nil {
+			t.Fatalf("bolt db update transaction %s", err)
+		}
+	})
+}
@@ -0,0 +1,302 @@
+// Copyright (c) 2026, Janoš Guljaš <janos@resenje.org>
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package boltutils
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	// journalBucketName is the name of the meta bucket with journal
+	// entries under big endian encoded sequence numbers.
+	journalBucketName = []byte("journal")
+	// appliedBucketName is the name of the meta bucket that holds the
+	// sequence of the last journal entry applied by ApplyJournal.
+	appliedBucketName  = []byte("journal-applied")
+	appliedSequenceKey = []byte("sequence")
+)
+
+// ErrJournalCompacted is returned by ReadJournal if journal entries that
+// follow the requested sequence are deleted by CompactJournal.
+var ErrJournalCompacted = errors.New("journal entries are compacted")
+
+// Journal entry operation codes.
+const (
+	journalOpPut byte = iota + 1
+	journalOpDelete
+	journalOpDeleteBucket
+	journalOpCreateBucket
+)
+
+// JournalEntry is a change recorded in the journal by DB methods.
+type JournalEntry struct {
+	// Sequence is the position of the entry in the journal, starting
+	// from 1.
+	Sequence uint64
+	// Time is when the change is made, with nanosecond precision.
+	Time time.Time
+	// Op is OpPut, OpDelete or OpDeleteBucket. Replication snapshots
+	// also use OpCreateBucket.
+	Op Op
+	// Path references the changed key, or the bucket for OpDeleteBucket
+	// and OpCreateBucket.
+	Path Path
+	// Value is the new value of the key for OpPut.
+	Value []byte
+	// Expires is the expiration time of the key for OpPut, if it is
+	// set.
+	Expires time.Time
+}
+
+// writeJournal appends the entry to the journal, assigning it the next
+// sequence and the current time.
+func writeJournal(tx *bolt.Tx, e JournalEntry) (err error) {
+	bucket, err := createMetaBucket(tx, journalBucketName)
+	if err != nil {
+		return err
+	}
+	seq, err := bucket.NextSequence()
+	if err != nil {
+		return fmt.Errorf("bucket %s next sequence: %w", path(MetaBucketName, journalBucketName), err)
+	}
+	e.Sequence = seq
+	e.Time = time.Now()
+	data, err := e.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	if err := bucket.Put(sequenceKey(seq), data); err != nil {
+		return fmt.Errorf("bucket %s put %d: %w", path(MetaBucketName, journalBucketName), seq, err)
+	}
+	return nil
+}
+
+// ReadJournal returns up to limit journal entries with sequence greater
+// than since, in the order in which they are written. If limit is zero
+// or negative, all such entries are returned. It returns
+// ErrJournalCompacted if the entry that follows since is deleted by
+// CompactJournal.
+func ReadJournal(tx *bolt.Tx, since uint64, limit int) (entries []JournalEntry, err error) {
+	bucket := metaBucket(tx, journalBucketName)
+	if bucket == nil || since >= bucket.Sequence() {
+		return nil, nil
+	}
+	c := bucket.Cursor()
+	if k, _ := c.First(); k == nil || binary.BigEndian.Uint64(k) > since+1 {
+		return nil, ErrJournalCompacted
+	}
+	for k, v := c.Seek(sequenceKey(since + 1)); k != nil; k, v = c.Next() {
+		if limit > 0 && len(entries) >= limit {
+			break
+		}
+		e := JournalEntry{Sequence: binary.BigEndian.Uint64(k)}
+		if err := e.UnmarshalBinary(v); err != nil {
+			return entries, fmt.Errorf("bucket %s key %x: %w", path(MetaBucketName, journalBucketName), k, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// JournalSequence returns the sequence of the last entry written to the
+// journal, or 0 if there are none. Compaction does not change it.
+func JournalSequence(tx *bolt.Tx) (seq uint64) {
+	bucket := metaBucket(tx, journalBucketName)
+	if bucket == nil {
+		return 0
+	}
+	return bucket.Sequence()
+}
+
+// CompactJournal deletes journal entries with sequence that is not
+// greater than the checkpoint, and returns the number of deleted
+// entries. Followers that have not applied entries up to the checkpoint
+// can not continue replaying the journal.
+func CompactJournal(tx *bolt.Tx, checkpoint uint64) (n int, err error) {
+	bucket := metaBucket(tx, journalBucketName)
+	if bucket == nil {
+		return 0, nil
+	}
+	c := bucket.Cursor()
+	for k, _ := c.First(); k != nil && binary.BigEndian.Uint64(k) <= checkpoint; k, _ = c.First() {
+		if err := c.Delete(); err != nil {
+			return n, fmt.Errorf("bucket %s delete %x: %w", path(MetaBucketName, journalBucketName), k, err)
+		}
+		n++
+	}
+	return n, nil
+}
+
+// ApplyJournal replays journal entries, usually read from another
+// database with ReadJournal, and records the sequence of the last
+// applied entry. Entries with sequence that is not greater than
+// AppliedSequence are skipped, so that the same entries can be applied
+// again after an interruption. Other entries must follow the last
+// applied entry without gaps, or an error is returned. Applied changes
+// are not written to the journal of this database.
+func ApplyJournal(tx *bolt.Tx, entries []JournalEntry) (err error) {
+	applied := AppliedSequence(tx)
+	for _, e := range entries {
+		if e.Sequence <= applied {
+			continue
+		}
+		if e.Sequence != applied+1 {
+			return fmt.Errorf("journal entry %d does not follow applied entry %d", e.Sequence, applied)
+		}
+		if err := applyJournalEntry(tx, e); err != nil {
+			return fmt.Errorf("journal entry %d: %w", e.Sequence, err)
+		}
+		applied = e.Sequence
+	}
+	return setAppliedSequence(tx, applied)
+}
+
+func applyJournalEntry(tx *bolt.Tx, e JournalEntry) (err error) {
+	switch e.Op {
+	case OpPut:
+		if e.Expires.IsZero() {
+			_, err = PathPut(tx, true, e.Path, e.Value)
+		} else {
+			_, err = PathPutWithExpiry(tx, true, e.Path, e.Value, e.Expires)
+		}
+		return err
+	case OpDelete:
+		return PathDelete(tx, false, e.Path)
+	case OpDeleteBucket:
+		return PathDeleteBucket(tx, false, e.Path)
+	case OpCreateBucket:
+		_, err = PathCreateBucketIfNotExists(tx, e.Path)
+		return err
+	}
+	return fmt.Errorf("unsupported operation %q", e.Op)
+}
+
+// AppliedSequence returns the sequence of the last journal entry
+// applied by ApplyJournal, or 0 if no entries are applied.
+func AppliedSequence(tx *bolt.Tx) (seq uint64) {
+	bucket := metaBucket(tx, appliedBucketName)
+	if bucket == nil {
+		return 0
+	}
+	v := bucket.Get(appliedSequenceKey)
+	if len(v) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(v)
+}
+
+func setAppliedSequence(tx *bolt.Tx, seq uint64) (err error) {
+	bucket, err := createMetaBucket(tx, appliedBucketName)
+	if err != nil {
+		return err
+	}
+	if err := bucket.Put(appliedSequenceKey, sequenceKey(seq)); err != nil {
+		return fmt.Errorf("bucket %s put %s: %w", path(MetaBucketName, appliedBucketName), appliedSequenceKey, err)
+	}
+	return nil
+}
+
+// MarshalBinary encodes the journal entry, except its sequence, into a
+// byte slice that can be decoded with UnmarshalBinary.
+func (e JournalEntry) MarshalBinary() (data []byte, err error) {
+	var op byte
+	switch e.Op {
+	case OpPut:
+		op = journalOpPut
+	case OpDelete:
+		op = journalOpDelete
+	case OpDeleteBucket:
+		op = journalOpDeleteBucket
+	case OpCreateBucket:
+		op = journalOpCreateBucket
+	default:
+		return nil, fmt.Errorf("unsupported operation %q", e.Op)
+	}
+	data = append(TimeToBytesUTC(e.Time), op)
+	p := e.Path.appendBinary(nil)
+	data = appendUvarint(data, uint64(len(p)))
+	data = append(data, p...)
+	if op != journalOpPut {
+		return data, nil
+	}
+	if e.Expires.IsZero() {
+		data = append(data, 0)
+	} else {
+		data = append(append(data, 1), TimeToBytesUTC(e.Expires)...)
+	}
+	return append(data, e.Value...), nil
+}
+
+// UnmarshalBinary decodes the journal entry encoded by MarshalBinary.
+// The sequence is not changed.
+func (e *JournalEntry) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < TimeBytesLen+1 {
+		return fmt.Errorf("journal entry header: %w", io.ErrUnexpectedEOF)
+	}
+	t := BytesToTimeUTC(data[:TimeBytesLen])
+	var op Op
+	switch data[TimeBytesLen] {
+	case journalOpPut:
+		op = OpPut
+	case journalOpDelete:
+		op = OpDelete
+	case journalOpDeleteBucket:
+		op = OpDeleteBucket
+	case journalOpCreateBucket:
+		op = OpCreateBucket
+	default:
+		return fmt.Errorf("invalid journal operation %d", data[TimeBytesLen])
+	}
+	pb, data, err := readLengthPrefixed(data[TimeBytesLen+1:])
+	if err != nil {
+		return fmt.Errorf("journal entry path: %w", err)
+	}
+	var p Path
+	if err := p.UnmarshalBinary(pb); err != nil {
+		return err
+	}
+	p = copyPath(p)
+	var value []byte
+	var expires time.Time
+	if op == OpPut {
+		if len(data) < 1 {
+			return fmt.Errorf("journal entry expiry flag: %w", io.ErrUnexpectedEOF)
+		}
+		switch data[0] {
+		case 0:
+			data = data[1:]
+		case 1:
+			if len(data) < TimeBytesLen+1 {
+				return fmt.Errorf("journal entry expiry: %w", io.ErrUnexpectedEOF)
+			}
+			expires = BytesToTimeUTC(data[1 : TimeBytesLen+1])
+			data = data[TimeBytesLen+1:]
+		default:
+			return fmt.Errorf("invalid journal entry expiry flag %d", data[0])
+		}
+		value = append([]byte{}, data...)
+	} else if len(data) > 0 {
+		return fmt.Errorf("journal entry has %d trailing bytes", len(data))
+	}
+	e.Time = t
+	e.Op = op
+	e.Path = p
+	e.Value = value
+	e.Expires = expires
+	return nil
+}
+
+func sequenceKey(seq uint64) []byte {
+	k := make([]byte, 8)
+	binary.BigEndian.PutUint64(k, seq)
+	return k
+}
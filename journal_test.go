@@ -0,0 +1,205 @@
+// Copyright (c) 2026, Janoš Guljaš <janos@resenje.org>
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package boltutils
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestJournal(t *testing.T) {
+	primary := newTestDB(t)
+	defer primary.Destroy()
+	follower := newTestDB(t)
+	defer follower.Destroy()
+
+	db := NewDB(primary.DB, &DBOptions{Journal: true})
+	ctx := context.Background()
+	start := time.Now()
+	expires := start.Add(time.Hour).Round(0)
+
+	if err := db.Update(ctx, func(tx *bolt.Tx) error {
+		if _, err := db.DeepPut(tx, true, []byte("a"), []byte("b"), []byte("k1"), []byte("v1")); err != nil {
+			return err
+		}
+		if _, err := db.DeepPut(tx, true, []byte("a"), []byte("k2"), []byte("v2")); err != nil {
+			return err
+		}
+		// Deletion of a missing key is not recorded.
+		return db.DeepDelete(tx, false, []byte("a"), []byte("missing"))
+	}); err != nil {
+		t.Fatalf("bolt db update transaction %s", err)
+	}
+	if _, err := db.Put(ctx, NewKeyPath([]byte("k3"), []byte("a"), []byte("c")), []byte("v3"), &PutOptions{TTL: time.Hour}); err != nil {
+		t.Fatalf("put: %s", err)
+	}
+	if err := db.Delete(ctx, true, NewKeyPath([]byte("k2"), []byte("a"))); err != nil {
+		t.Fatalf("delete: %s", err)
+	}
+	if _, err := db.Put(ctx, NewKeyPath([]byte("k4"), []byte("x")), []byte("v4"), nil); err != nil {
+		t.Fatalf("put: %s", err)
+	}
+	if err := db.DeleteBucket(ctx, true, NewPath([]byte("x"))); err != nil {
+		t.Fatalf("delete bucket: %s", err)
+	}
+	// Changes made without DB methods are not recorded.
+	if err := primary.DB.Update(func(tx *bolt.Tx) error {
+		_, err := DeepPut(tx, true, []byte("y"), []byte("k5"), []byte("v5"))
+		return err
+	}); err != nil {
+		t.Fatalf("bolt db update transaction %s", err)
+	}
+
+	var entries []JournalEntry
+	if err := primary.DB.View(func(tx *bolt.Tx) (err error) {
+		if seq := JournalSequence(tx); seq != 6 {
+			t.Errorf("got journal sequence %d, want %d", seq, 6)
+		}
+		entries, err = ReadJournal(tx, 0, 0)
+		return err
+	}); err != nil {
+		t.Fatalf("bolt db view transaction %s", err)
+	}
+	var got []string
+	for i, e := range entries {
+		if e.Time.Before(start) || e.Time.After(time.Now()) {
+			t.Errorf("entry %d: invalid time %s", i, e.Time)
+		}
+		s := fmt.Sprintf("%d %s %s %q", e.Sequence, e.Op, e.Path, e.Value)
+		if !e.Expires.IsZero() {
+			s += " expires"
+			if d := e.Expires.Sub(expires); d > time.Minute || d < -time.Minute {
+				t.Errorf("entry %d: invalid expiration time %s", i, e.Expires)
+			}
+		}
+		got = append(got, s)
+	}
+	want := []string{
+		`1 put a/b/k1 "v1"`,
+		`2 put a/k2 "v2"`,
+		`3 put a/c/k3 "v3" expires`,
+		`4 delete a/k2 ""`,
+		`5 put x/k4 "v4"`,
+		`6 delete bucket x ""`,
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got entries %q, want %q", got, want)
+	}
+
+	if err := primary.DB.View(func(tx *bolt.Tx) error {
+		entries, err := ReadJournal(tx, 4, 1)
+		if err != nil {
+			return err
+		}
+		if len(entries) != 1 || entries[0].Sequence != 5 {
+			t.Errorf("got entries %v", entries)
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("bolt db view transaction %s", err)
+	}
+
+	apply := func(entries []JournalEntry) {
+		t.Helper()
+		if err := follower.DB.Update(func(tx *bolt.Tx) error {
+			return ApplyJournal(tx, entries)
+		}); err != nil {
+			t.Fatalf("bolt db update transaction %s", err)
+		}
+	}
+	apply(entries[:4])
+	// Entries that are already applied are skipped.
+	apply(entries)
+	if err := follower.DB.View(func(tx *bolt.Tx) error {
+		if seq := AppliedSequence(tx); seq != 6 {
+			t.Errorf("got applied sequence %d, want %d", seq, 6)
+		}
+		if seq := JournalSequence(tx); seq != 0 {
+			t.Errorf("got journal sequence %d, want %d", seq, 0)
+		}
+		if _, ok := PathExpiry(tx, NewKeyPath([]byte("k3"), []byte("a"), []byte("c"))); !ok {
+			t.Error("expiry not applied")
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("bolt db view transaction %s", err)
+	}
+	// Entries that do not follow the last applied entry are rejected.
+	if err := follower.DB.Update(func(tx *bolt.Tx) error {
+		return ApplyJournal(tx, []JournalEntry{{Sequence: 8, Op: OpDelete, Path: NewKeyPath([]byte("k"), []byte("a"))}})
+	}); err == nil {
+		t.Error("no error for a gap in entries")
+	}
+	for _, p := range []Path{NewPath([]byte("a")), NewPath([]byte("x"))} {
+		if got, want := dump(t, follower, p), dump(t, primary, p); fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("%s: got %q, want %q", p, got, want)
+		}
+	}
+
+	if err := primary.DB.Update(func(tx *bolt.Tx) error {
+		n, err := CompactJournal(tx, 4)
+		if err != nil {
+			return err
+		}
+		if n != 4 {
+			t.Errorf("got %d compacted entries, want %d", n, 4)
+		}
+		if _, err := ReadJournal(tx, 3, 0); err != ErrJournalCompacted {
+			t.Errorf("got error %v, want %v", err, ErrJournalCompacted)
+		}
+		entries, err := ReadJournal(tx, 4, 0)
+		if err != nil {
+			return err
+		}
+		if len(entries) != 2 || entries[0].Sequence != 5 {
+			t.Errorf("got entries %v", entries)
+		}
+		if entries, err := ReadJournal(tx, 6, 0); err != nil || len(entries) != 0 {
+			t.Errorf("got entries %v, error %v", entries, err)
+		}
+		if seq := JournalSequence(tx); seq != 6 {
+			t.Errorf("got journal sequence %d, want %d", seq, 6)
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("bolt db update transaction %s", err)
+	}
+}
+
+func TestJournalEntryBinary(t *testing.T) {
+	now := time.Now().UTC()
+	for _, e := range []JournalEntry{
+		{Time: now, Op: OpPut, Path: NewKeyPath([]byte("k"), []byte("a")), Value: []byte("v")},
+		{Time: now, Op: OpPut, Path: NewKeyPath([]byte{}, []byte("a")), Value: []byte{}, Expires: now.Add(time.Hour)},
+		{Time: now, Op: OpDelete, Path: NewKeyPath([]byte("k"), []byte("a"), []byte("b"))},
+		{Time: now, Op: OpDeleteBucket, Path: NewPath([]byte("a"))},
+		{Time: now, Op: OpCreateBucket, Path: NewPath([]byte("a"), []byte("b"))},
+	} {
+		data, err := e.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got JournalEntry
+		if err := got.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+		if !got.Time.Equal(e.Time) || got.Op != e.Op || !got.Path.Equal(e.Path) || string(got.Value) != string(e.Value) || !got.Expires.Equal(e.Expires) {
+			t.Errorf("got %+v, want %+v", got, e)
+		}
+		for i := 0; i < len(data); i++ {
+			if err := new(JournalEntry).UnmarshalBinary(data[:i]); err == nil && e.Op != OpPut {
+				t.Errorf("%s: no error for %d bytes", e.Op, i)
+			}
+		}
+	}
+	if _, err := (JournalEntry{Op: OpCopyBucket}).MarshalBinary(); err == nil {
+		t.Error("no error for unsupported operation")
+	}
+}
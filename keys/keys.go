@@ -0,0 +1,154 @@
+// Copyright (c) 2026, Janoš Guljaš <janos@resenje.org>
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package keys provides order-preserving encodings of values to byte
+// slices. Byte-wise comparison of encoded values, as done by Bolt for
+// keys, gives the same order as the comparison of the original values.
+package keys // import "resenje.org/boltutils/keys"
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// Lengths of fixed size encodings.
+const (
+	Int64Len   = 8
+	Uint64Len  = 8
+	Float64Len = 8
+	BoolLen    = 1
+	UUIDLen    = 16
+)
+
+// UUID is a universally unique identifier. Its encoding is the same as
+// its binary form, so UUIDs are sorted by bytes.
+type UUID [UUIDLen]byte
+
+// LengthError is returned by decoding functions when the input has an
+// invalid length.
+type LengthError struct {
+	Type   string
+	Length int
+	Want   int
+}
+
+func (e *LengthError) Error() string {
+	return fmt.Sprintf("invalid %s length %d, expected %d", e.Type, e.Length, e.Want)
+}
+
+// EncodeInt64 returns the encoding of a signed integer. Negative numbers
+// are sorted before positive ones.
+func EncodeInt64(v int64) (b []byte) {
+	b = make([]byte, Int64Len)
+	PutInt64(b, v)
+	return b
+}
+
+// PutInt64 puts the encoding of a signed integer to the provided slice.
+// The slice must have the length of at least Int64Len bytes.
+func PutInt64(b []byte, v int64) {
+	binary.BigEndian.PutUint64(b, uint64(v)^(1<<63))
+}
+
+// DecodeInt64 decodes a signed integer encoded by EncodeInt64.
+func DecodeInt64(b []byte) (v int64, err error) {
+	if len(b) != Int64Len {
+		return 0, &LengthError{Type: "int64", Length: len(b), Want: Int64Len}
+	}
+	return int64(binary.BigEndian.Uint64(b) ^ (1 << 63)), nil
+}
+
+// EncodeUint64 returns the encoding of an unsigned integer.
+func EncodeUint64(v uint64) (b []byte) {
+	b = make([]byte, Uint64Len)
+	binary.BigEndian.PutUint64(b, v)
+	return b
+}
+
+// DecodeUint64 decodes an unsigned integer encoded by EncodeUint64.
+func DecodeUint64(b []byte) (v uint64, err error) {
+	if len(b) != Uint64Len {
+		return 0, &LengthError{Type: "uint64", Length: len(b), Want: Uint64Len}
+	}
+	return binary.BigEndian.Uint64(b), nil
+}
+
+// EncodeFloat64 returns the encoding of a floating point number. The
+// order is the same as of math.Float64bits total ordering, where
+// negative zero is sorted before positive zero and NaN values with the
+// sign bit set are sorted before all other numbers, and the rest after.
+func EncodeFloat64(v float64) (b []byte) {
+	b = make([]byte, Float64Len)
+	PutFloat64(b, v)
+	return b
+}
+
+// PutFloat64 puts the encoding of a floating point number to the
+// provided slice. The slice must have the length of at least Float64Len
+// bytes.
+func PutFloat64(b []byte, v float64) {
+	u := math.Float64bits(v)
+	if u&(1<<63) != 0 {
+		u = ^u
+	} else {
+		u |= 1 << 63
+	}
+	binary.BigEndian.PutUint64(b, u)
+}
+
+// DecodeFloat64 decodes a floating point number encoded by
+// EncodeFloat64.
+func DecodeFloat64(b []byte) (v float64, err error) {
+	if len(b) != Float64Len {
+		return 0, &LengthError{Type: "float64", Length: len(b), Want: Float64Len}
+	}
+	u := binary.BigEndian.Uint64(b)
+	if u&(1<<63) != 0 {
+		u &^= 1 << 63
+	} else {
+		u = ^u
+	}
+	return math.Float64frombits(u), nil
+}
+
+// EncodeBool returns the encoding of a boolean value. False is sorted
+// before true.
+func EncodeBool(v bool) (b []byte) {
+	if v {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+
+// DecodeBool decodes a boolean value encoded by EncodeBool.
+func DecodeBool(b []byte) (v bool, err error) {
+	if len(b) != BoolLen {
+		return false, &LengthError{Type: "bool", Length: len(b), Want: BoolLen}
+	}
+	switch b[0] {
+	case 0:
+		return false, nil
+	case 1:
+		return true, nil
+	}
+	return false, fmt.Errorf("invalid bool value %d", b[0])
+}
+
+// EncodeUUID returns the encoding of a UUID.
+func EncodeUUID(v UUID) (b []byte) {
+	b = make([]byte, UUIDLen)
+	copy(b, v[:])
+	return b
+}
+
+// DecodeUUID decodes a UUID encoded by EncodeUUID.
+func DecodeUUID(b []byte) (v UUID, err error) {
+	if len(b) != UUIDLen {
+		return v, &LengthError{Type: "uuid", Length: len(b), Want: UUIDLen}
+	}
+	copy(v[:], b)
+	return v, nil
+}
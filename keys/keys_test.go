@@ -0,0 +1,126 @@
+// Copyright (c) 2026, Janoš Guljaš <janos@resenje.org>
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package keys_test
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"resenje.org/boltutils/keys"
+)
+
+func TestInt64(t *testing.T) {
+	values := []int64{math.MinInt64, -1 << 40, -256, -1, 0, 1, 255, 1 << 40, math.MaxInt64}
+	var prev []byte
+	for _, v := range values {
+		b := keys.EncodeInt64(v)
+		if prev != nil && bytes.Compare(prev, b) >= 0 {
+			t.Errorf("%d: encoding %x is not greater than %x", v, b, prev)
+		}
+		prev = b
+		got, err := keys.DecodeInt64(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != v {
+			t.Errorf("got %d, want %d", got, v)
+		}
+	}
+	if _, err := keys.DecodeInt64([]byte{1}); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestUint64(t *testing.T) {
+	values := []uint64{0, 1, 255, 1 << 40, math.MaxUint64}
+	var prev []byte
+	for _, v := range values {
+		b := keys.EncodeUint64(v)
+		if prev != nil && bytes.Compare(prev, b) >= 0 {
+			t.Errorf("%d: encoding %x is not greater than %x", v, b, prev)
+		}
+		prev = b
+		got, err := keys.DecodeUint64(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != v {
+			t.Errorf("got %d, want %d", got, v)
+		}
+	}
+}
+
+func TestFloat64(t *testing.T) {
+	values := []float64{
+		math.Inf(-1),
+		-math.MaxFloat64,
+		-1e10,
+		-1.5,
+		-math.SmallestNonzeroFloat64,
+		math.Copysign(0, -1),
+		0,
+		math.SmallestNonzeroFloat64,
+		1.5,
+		1e10,
+		math.MaxFloat64,
+		math.Inf(1),
+	}
+	var prev []byte
+	for _, v := range values {
+		b := keys.EncodeFloat64(v)
+		if prev != nil && bytes.Compare(prev, b) >= 0 {
+			t.Errorf("%g: encoding %x is not greater than %x", v, b, prev)
+		}
+		prev = b
+		got, err := keys.DecodeFloat64(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Float64bits(got) != math.Float64bits(v) {
+			t.Errorf("got %g, want %g", got, v)
+		}
+	}
+	got, err := keys.DecodeFloat64(keys.EncodeFloat64(math.NaN()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !math.IsNaN(got) {
+		t.Errorf("got %g, want NaN", got)
+	}
+}
+
+func TestBool(t *testing.T) {
+	if bytes.Compare(keys.EncodeBool(false), keys.EncodeBool(true)) >= 0 {
+		t.Error("false is not sorted before true")
+	}
+	for _, v := range []bool{false, true} {
+		got, err := keys.DecodeBool(keys.EncodeBool(v))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != v {
+			t.Errorf("got %v, want %v", got, v)
+		}
+	}
+	if _, err := keys.DecodeBool([]byte{2}); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestUUID(t *testing.T) {
+	v := keys.UUID{0x12, 0x3e, 0x45, 0x67, 0xe8, 0x9b, 0x12, 0xd3, 0xa4, 0x56, 0x42, 0x66, 0x14, 0x17, 0x40, 0x00}
+	got, err := keys.DecodeUUID(keys.EncodeUUID(v))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != v {
+		t.Errorf("got %x, want %x", got, v)
+	}
+	if _, err := keys.DecodeUUID(v[:15]); err == nil {
+		t.Error("expected error")
+	}
+}
@@ -0,0 +1,201 @@
+// Copyright (c) 2026, Janoš Guljaš <janos@resenje.org>
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package keys
+
+import (
+	"fmt"
+	"time"
+
+	"resenje.org/boltutils"
+)
+
+// Natural is a string that is encoded in a tuple with natural sort
+// ordering, as returned by boltutils.NaturalReversible.
+type Natural string
+
+// Tuple element type codes. Elements of different types at the same
+// position in tuples are sorted by these codes.
+const (
+	tupleBytes   byte = 0x01
+	tupleString  byte = 0x02
+	tupleNatural byte = 0x03
+	tupleTime    byte = 0x04
+	tupleInt64   byte = 0x05
+	tupleUint64  byte = 0x06
+	tupleFloat64 byte = 0x07
+	tupleBool    byte = 0x08
+	tupleUUID    byte = 0x09
+)
+
+// EncodeTuple returns the encoding of multiple values, such that
+// encoded tuples are sorted by the first value, then by the second and
+// so on. A tuple that is a prefix of another tuple is sorted before it.
+// Supported value types are []byte, string, Natural, time.Time, int,
+// int32, int64, uint, uint32, uint64, float64, bool and UUID. Time is
+// encoded with boltutils.TimeToBytesUTC and its location is not
+// preserved.
+func EncodeTuple(values ...any) (b []byte, err error) {
+	return AppendTuple(nil, values...)
+}
+
+// AppendTuple appends the encoding of multiple values, as returned by
+// EncodeTuple, to the provided slice.
+func AppendTuple(dst []byte, values ...any) (b []byte, err error) {
+	b = dst
+	for i, v := range values {
+		switch v := v.(type) {
+		case []byte:
+			b = append(b, tupleBytes)
+			b = appendEscaped(b, v)
+		case string:
+			b = append(b, tupleString)
+			b = appendEscaped(b, []byte(v))
+		case Natural:
+			b = append(b, tupleNatural)
+			b = appendEscaped(b, boltutils.NaturalReversibleBytes([]byte(v)))
+		case time.Time:
+			b = append(b, tupleTime)
+			b = append(b, boltutils.TimeToBytesUTC(v)...)
+		case int:
+			b = append(b, tupleInt64)
+			b = append(b, EncodeInt64(int64(v))...)
+		case int32:
+			b = append(b, tupleInt64)
+			b = append(b, EncodeInt64(int64(v))...)
+		case int64:
+			b = append(b, tupleInt64)
+			b = append(b, EncodeInt64(v)...)
+		case uint:
+			b = append(b, tupleUint64)
+			b = append(b, EncodeUint64(uint64(v))...)
+		case uint32:
+			b = append(b, tupleUint64)
+			b = append(b, EncodeUint64(uint64(v))...)
+		case uint64:
+			b = append(b, tupleUint64)
+			b = append(b, EncodeUint64(v)...)
+		case float64:
+			b = append(b, tupleFloat64)
+			b = append(b, EncodeFloat64(v)...)
+		case bool:
+			b = append(b, tupleBool)
+			b = append(b, EncodeBool(v)...)
+		case UUID:
+			b = append(b, tupleUUID)
+			b = append(b, v[:]...)
+		default:
+			return nil, fmt.Errorf("tuple element %d: unsupported type %T", i, v)
+		}
+	}
+	return b, nil
+}
+
+// DecodeTuple decodes values encoded by EncodeTuple. Integers are
+// returned as int64 or uint64, and times in UTC location.
+func DecodeTuple(b []byte) (values []any, err error) {
+	for i := 0; len(b) > 0; i++ {
+		t := b[0]
+		b = b[1:]
+		var v any
+		switch t {
+		case tupleBytes:
+			var e []byte
+			e, b, err = readEscaped(b)
+			v = e
+		case tupleString:
+			var e []byte
+			e, b, err = readEscaped(b)
+			v = string(e)
+		case tupleNatural:
+			var e []byte
+			e, b, err = readEscaped(b)
+			if err == nil {
+				e, err = boltutils.NaturalDecodeBytes(e)
+				v = Natural(e)
+			}
+		case tupleTime:
+			var e []byte
+			e, b, err = readFixed(b, boltutils.TimeBytesLen)
+			if err == nil {
+				v = boltutils.BytesToTimeUTC(e)
+			}
+		case tupleInt64:
+			var e []byte
+			e, b, err = readFixed(b, Int64Len)
+			if err == nil {
+				v, err = DecodeInt64(e)
+			}
+		case tupleUint64:
+			var e []byte
+			e, b, err = readFixed(b, Uint64Len)
+			if err == nil {
+				v, err = DecodeUint64(e)
+			}
+		case tupleFloat64:
+			var e []byte
+			e, b, err = readFixed(b, Float64Len)
+			if err == nil {
+				v, err = DecodeFloat64(e)
+			}
+		case tupleBool:
+			var e []byte
+			e, b, err = readFixed(b, BoolLen)
+			if err == nil {
+				v, err = DecodeBool(e)
+			}
+		case tupleUUID:
+			var e []byte
+			e, b, err = readFixed(b, UUIDLen)
+			if err == nil {
+				v, err = DecodeUUID(e)
+			}
+		default:
+			err = fmt.Errorf("unknown type code %#x", t)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("tuple element %d: %w", i, err)
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// appendEscaped appends the data with every zero byte escaped as 0x00
+// 0xff and terminated with a single zero byte.
+func appendEscaped(dst, data []byte) []byte {
+	for _, c := range data {
+		if c == 0 {
+			dst = append(dst, 0, 0xff)
+			continue
+		}
+		dst = append(dst, c)
+	}
+	return append(dst, 0)
+}
+
+func readEscaped(b []byte) (data, rest []byte, err error) {
+	data = []byte{}
+	for i := 0; i < len(b); i++ {
+		if b[i] != 0 {
+			data = append(data, b[i])
+			continue
+		}
+		if i+1 < len(b) && b[i+1] == 0xff {
+			data = append(data, 0)
+			i++
+			continue
+		}
+		return data, b[i+1:], nil
+	}
+	return nil, nil, fmt.Errorf("unterminated value")
+}
+
+func readFixed(b []byte, n int) (data, rest []byte, err error) {
+	if len(b) < n {
+		return nil, nil, &LengthError{Type: "tuple element", Length: len(b), Want: n}
+	}
+	return b[:n], b[n:], nil
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2026, Janoš Guljaš <janos@resenje.org>
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package keys_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"resenje.org/boltutils/keys"
+)
+
+func TestTuple(t *testing.T) {
+	now := time.Date(2018, 6, 28, 8, 15, 0, 659847297, time.UTC)
+	values := []any{
+		[]byte{0, 1, 0},
+		"string\x00with zero",
+		keys.Natural("file10.txt"),
+		now,
+		int64(-42),
+		uint64(42),
+		3.14,
+		true,
+		keys.UUID{1, 2, 3},
+	}
+	b, err := keys.EncodeTuple(values...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := keys.DecodeTuple(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, values) {
+		t.Errorf("got %v, want %v", got, values)
+	}
+
+	if _, err := keys.EncodeTuple(struct{}{}); err == nil {
+		t.Error("expected error for unsupported type")
+	}
+	if _, err := keys.DecodeTuple(b[:len(b)-1]); err == nil {
+		t.Error("expected error for truncated tuple")
+	}
+}
+
+func TestTupleOrder(t *testing.T) {
+	now := time.Now()
+	// Tuples in ascending order.
+	tuples := [][]any{
+		{"a"},
+		{"a", int64(-1)},
+		{"a", int64(0)},
+		{"a", int64(0), "x"},
+		{"a\x00"},
+		{"ab"},
+		{"b", keys.Natural("file2")},
+		{"b", keys.Natural("file10")},
+		{"b", keys.Natural("file10"), now},
+		{"b", keys.Natural("file10"), now.Add(time.Nanosecond)},
+		{"c", -1.5, false},
+		{"c", -1.5, true},
+		{"c", 0.5},
+	}
+	var prev []byte
+	for _, tuple := range tuples {
+		b, err := keys.EncodeTuple(tuple...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if prev != nil && bytes.Compare(prev, b) >= 0 {
+			t.Errorf("%v: encoding %x is not greater than %x", tuple, b, prev)
+		}
+		prev = b
+	}
+}
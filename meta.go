@@ -0,0 +1,194 @@
+// Copyright (c) 2026, Janoš Guljaš <janos@resenje.org>
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package boltutils
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// MetaBucketName is the name of the top level bucket in which this
+// package keeps its own data, such as key expiration times. It should
+// not be used by applications for other purposes.
+var MetaBucketName = []byte("__boltutils")
+
+// metaBucket returns the bucket with the provided name in the meta
+// bucket, or nil if it does not exist.
+func metaBucket(tx *bolt.Tx, name []byte) (bucket *bolt.Bucket) {
+	meta := tx.Bucket(MetaBucketName)
+	if meta == nil {
+		return nil
+	}
+	return meta.Bucket(name)
+}
+
+// createMetaBucket returns the bucket with the provided name in the meta
+// bucket, creating both buckets if they do not exist.
+func createMetaBucket(tx *bolt.Tx, name []byte) (bucket *bolt.Bucket, err error) {
+	meta, err := tx.CreateBucketIfNotExists(MetaBucketName)
+	if err != nil {
+		return nil, fmt.Errorf("bucket create %s: %w", MetaBucketName, err)
+	}
+	bucket, err = meta.CreateBucketIfNotExists(name)
+	if err != nil {
+		return nil, fmt.Errorf("bucket create %s: %w", path(MetaBucketName, name), err)
+	}
+	return bucket, nil
+}
+
+// appendMetaKey appends the encoding of the key path that is used as a
+// key in meta buckets. Every bucket name is prefixed by its length
+// increased by one, and the key follows a zero byte, so that the encoded
+// buckets of a path, as appended by appendMetaPrefix, are a prefix of
+// the encoded paths of all keys in that bucket and its nested buckets.
+func appendMetaKey(data []byte, p Path) []byte {
+	data = appendMetaPrefix(data, p)
+	data = append(data, 0)
+	return append(data, p.Key...)
+}
+
+// appendMetaPrefix appends the encoded buckets of the path, as in
+// appendMetaKey, without the key.
+func appendMetaPrefix(data []byte, p Path) []byte {
+	for _, b := range p.Buckets {
+		data = appendUvarint(data, uint64(len(b))+1)
+		data = append(data, b...)
+	}
+	return data
+}
+
+// parseMetaKey decodes the key path encoded by appendMetaKey.
+func parseMetaKey(data []byte) (p Path, err error) {
+	for {
+		l, rest, err := readUvarint(data)
+		if err != nil {
+			return Path{}, fmt.Errorf("meta key bucket %d: %w", len(p.Buckets), err)
+		}
+		if l == 0 {
+			p.Key = append([]byte{}, rest...)
+			return p, nil
+		}
+		l--
+		if l > uint64(len(rest)) {
+			return Path{}, fmt.Errorf("meta key bucket %d: %w", len(p.Buckets), io.ErrUnexpectedEOF)
+		}
+		p.Buckets = append(p.Buckets, rest[:l])
+		data = rest[l:]
+	}
+}
+
+// metaEntry is an entry in a meta bucket that is keyed by the path of a
+// key encoded with appendMetaKey.
+type metaEntry struct {
+	key   []byte
+	path  Path
+	value []byte
+}
+
+// bucketMetaEntries returns entries of the meta bucket with the provided
+// name for all keys in the bucket referenced by the path and its nested
+// buckets.
+func bucketMetaEntries(tx *bolt.Tx, name []byte, p Path) (entries []metaEntry, err error) {
+	bucket := metaBucket(tx, name)
+	if bucket == nil {
+		return nil, nil
+	}
+	prefix := appendMetaPrefix(nil, p)
+	upper := prefixSuccessor(prefix)
+	c := bucket.Cursor()
+	for k, v := c.Seek(prefix); k != nil; k, v = c.Next() {
+		if upper != nil && bytes.Compare(k, upper) >= 0 {
+			break
+		}
+		k = append([]byte(nil), k...)
+		e, err := parseMetaKey(k)
+		if err != nil {
+			return nil, fmt.Errorf("bucket %s key %x: %w", path(MetaBucketName, name), k, err)
+		}
+		entries = append(entries, metaEntry{key: k, path: e, value: append([]byte(nil), v...)})
+	}
+	return entries, nil
+}
+
+// deleteBucketMeta removes expiration times and versions of all keys in
+// the bucket referenced by the path and its nested buckets.
+func deleteBucketMeta(tx *bolt.Tx, p Path) (err error) {
+	entries, err := bucketMetaEntries(tx, ttlKeysBucketName, p)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := clearExpiry(tx, e.path); err != nil {
+			return err
+		}
+	}
+	entries, err = bucketMetaEntries(tx, versionsBucketName, p)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := clearVersion(tx, e.path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyBucketExpiry sets expiration times of keys copied from the bucket
+// referenced by the src path in the srcTx transaction to the bucket
+// referenced by the dst path in the dstTx transaction, and deletes the
+// copies of keys that are expired at the provided time.
+func copyBucketExpiry(srcTx, dstTx *bolt.Tx, src, dst Path, now time.Time) (err error) {
+	entries, err := bucketMetaEntries(srcTx, ttlKeysBucketName, src)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if len(e.value) != TimeBytesLen {
+			continue
+		}
+		p := e.path.rebase(src, dst)
+		if expires := BytesToTimeUTC(e.value); expires.After(now) {
+			if err := setExpiry(dstTx, p, expires); err != nil {
+				return err
+			}
+			continue
+		}
+		if bucket := PathBucket(dstTx, p); bucket != nil {
+			if err := bucket.Delete(p.Key); err != nil {
+				return fmt.Errorf("bucket %s delete %s: %w", path(p.Buckets...), p.Key, err)
+			}
+		}
+	}
+	return nil
+}
+
+// moveBucketVersions moves versions of all keys in the bucket referenced
+// by the src path and its nested buckets to the same keys in the bucket
+// referenced by the dst path, if those keys exist.
+func moveBucketVersions(tx *bolt.Tx, src, dst Path) (err error) {
+	entries, err := bucketMetaEntries(tx, versionsBucketName, src)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := clearVersion(tx, e.path); err != nil {
+			return err
+		}
+		p := e.path.rebase(src, dst)
+		if bucket := PathBucket(tx, p); bucket == nil || bucket.Get(p.Key) == nil {
+			continue
+		}
+		if err := metaBucket(tx, versionsBucketName).Put(appendMetaKey(nil, p), e.value); err != nil {
+			return fmt.Errorf("bucket %s put %s: %w", path(MetaBucketName, versionsBucketName), p, err)
+		}
+	}
+	return nil
+}
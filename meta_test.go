@@ -0,0 +1,52 @@
+// Copyright (c) 2026, Janoš Guljaš <janos@resenje.org>
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package boltutils
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMetaKey(t *testing.T) {
+	paths := []Path{
+		NewKeyPath([]byte("key"), []byte("a")),
+		NewKeyPath([]byte("key"), []byte("a"), []byte("b")),
+		NewKeyPath([]byte{}, []byte("ab")),
+		NewKeyPath([]byte{0, 1}, []byte{}, []byte{0}),
+		NewKeyPath([]byte("key")),
+	}
+	for _, p := range paths {
+		got, err := parseMetaKey(appendMetaKey(nil, p))
+		if err != nil {
+			t.Errorf("path %q: %s", p.Elements(), err)
+			continue
+		}
+		if !got.Equal(p) {
+			t.Errorf("got %q, want %q", got.Elements(), p.Elements())
+		}
+	}
+
+	// Encoded buckets are a prefix only of keys in them.
+	for _, b := range []Path{NewPath([]byte("a")), NewPath([]byte("a"), []byte("b")), NewPath([]byte("ab"))} {
+		prefix := appendMetaPrefix(nil, b)
+		for _, p := range paths {
+			if got, want := bytes.HasPrefix(appendMetaKey(nil, p), prefix), p.HasPrefix(b); got != want {
+				t.Errorf("bucket %s path %q: got prefix %v, want %v", b, p.Elements(), got, want)
+			}
+		}
+	}
+
+	for _, data := range [][]byte{
+		nil,
+		{2, 'a'},
+		{3, 'a'},
+		{0x80},
+	} {
+		if _, err := parseMetaKey(data); err == nil {
+			t.Errorf("data %v: expected error", data)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+// Copyright (c) 2026, Janoš Guljaš <janos@resenje.org>
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package boltutils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	// schemaBucketName is the name of the meta bucket that holds the
+	// schema version and the progress of chunked migrations.
+	schemaBucketName = []byte("schema")
+	// schemaVersionKey is the key of the current schema version.
+	schemaVersionKey = []byte("version")
+	// schemaProgressKey is the key of the chunked migration progress,
+	// composed of a direction byte, the target version and the position
+	// of the next chunk.
+	schemaProgressKey = []byte("progress")
+)
+
+// MigrateFunc changes the data in a single transaction. It can use any
+// function from this package, such as DeepPut or DeepMoveBucket.
+type MigrateFunc func(tx *bolt.Tx) error
+
+// ChunkFunc changes a part of the data in a single transaction, starting
+// from the position returned by its previous call, or from the beginning
+// if the position is nil. It returns the position of the next chunk, or
+// nil when all data is migrated.
+type ChunkFunc func(tx *bolt.Tx, position []byte) (next []byte, err error)
+
+// Migration is a single step in the schema evolution. Up or UpChunked
+// must be set, and if both are, Up is used. Down or DownChunked are
+// required only to migrate to lower versions.
+type Migration struct {
+	// Name is an optional description used in error messages.
+	Name string
+	// Up upgrades the data in a single transaction.
+	Up MigrateFunc
+	// UpChunked upgrades the data in multiple transactions.
+	UpChunked ChunkFunc
+	// Down reverts the changes made by the upgrade in a single
+	// transaction.
+	Down MigrateFunc
+	// DownChunked reverts the changes made by the upgrade in multiple
+	// transactions.
+	DownChunked ChunkFunc
+}
+
+// VersionError is returned by Migrator if the schema version of the
+// database is higher than the number of registered migrations, meaning
+// that the database was used by a newer version of the application.
+type VersionError struct {
+	Version uint64
+	Latest  uint64
+}
+
+func (e *VersionError) Error() string {
+	return fmt.Sprintf("schema version %d is newer than the latest known version %d", e.Version, e.Latest)
+}
+
+// MigrateOptions holds optional parameters for Migrator.
+type MigrateOptions struct {
+	// DryRun runs all migration steps in a single transaction that is
+	// rolled back, even if it succeeds.
+	DryRun bool
+}
+
+var (
+	// errDryRun is used to roll back dry run transactions.
+	errDryRun = errors.New("dry run")
+	// errProgress is returned when a migration step is started while a
+	// different chunked step is not completed.
+	errProgress = errors.New("other chunked migration in progress")
+)
+
+// Migrator applies migrations to a database and keeps track of its
+// schema version in the meta bucket. The schema version is the number of
+// applied migrations, and a database without it has version zero.
+type Migrator struct {
+	db         *bolt.DB
+	migrations []Migration
+}
+
+// NewMigrator returns a new Migrator with migrations in the order in
+// which they should be applied. A migration at index i upgrades the
+// database from version i to version i+1.
+func NewMigrator(db *bolt.DB, migrations ...Migration) (m *Migrator) {
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+	}
+}
+
+// Latest returns the schema version after all migrations are applied.
+func (m *Migrator) Latest() (version uint64) {
+	return uint64(len(m.migrations))
+}
+
+// Version returns the current schema version of the database. It returns
+// VersionError if the database is newer than the latest known version.
+func (m *Migrator) Version() (version uint64, err error) {
+	if err := m.db.View(func(tx *bolt.Tx) (err error) {
+		version, err = SchemaVersion(tx)
+		return err
+	}); err != nil {
+		return 0, err
+	}
+	if latest := m.Latest(); version > latest {
+		return version, &VersionError{Version: version, Latest: latest}
+	}
+	return version, nil
+}
+
+// Migrate applies all pending migrations.
+func (m *Migrator) Migrate(opts *MigrateOptions) (err error) {
+	return m.MigrateTo(m.Latest(), opts)
+}
+
+// MigrateTo applies up or down migration steps in order until the
+// database has the provided schema version. Each step is applied in its
+// own transaction, or in multiple transactions if it is chunked. An
+// interrupted chunked step is resumed from its last committed chunk when
+// MigrateTo is called again with the same target direction.
+func (m *Migrator) MigrateTo(version uint64, opts *MigrateOptions) (err error) {
+	if opts == nil {
+		opts = new(MigrateOptions)
+	}
+	if latest := m.Latest(); version > latest {
+		return fmt.Errorf("unknown schema version %d > %d", version, latest)
+	}
+	current, err := m.Version()
+	if err != nil {
+		return err
+	}
+	for v := current; v > version; v-- {
+		if mg := m.migrations[v-1]; mg.Down == nil && mg.DownChunked == nil {
+			return fmt.Errorf("migration %s has no down step", m.name(v))
+		}
+	}
+	for v := current; v < version; v++ {
+		if mg := m.migrations[v]; mg.Up == nil && mg.UpChunked == nil {
+			return fmt.Errorf("migration %s has no up step", m.name(v+1))
+		}
+	}
+
+	if opts.DryRun {
+		err := m.db.Update(func(tx *bolt.Tx) error {
+			for v := current; v < version; v++ {
+				mg := m.migrations[v]
+				if err := runStep(tx, mg.Up, mg.UpChunked); err != nil {
+					return fmt.Errorf("migration %s up: %w", m.name(v+1), err)
+				}
+			}
+			for v := current; v > version; v-- {
+				mg := m.migrations[v-1]
+				if err := runStep(tx, mg.Down, mg.DownChunked); err != nil {
+					return fmt.Errorf("migration %s down: %w", m.name(v), err)
+				}
+			}
+			return errDryRun
+		})
+		if err == errDryRun {
+			return nil
+		}
+		return err
+	}
+
+	for v := current; v < version; v++ {
+		mg := m.migrations[v]
+		if err := m.step(true, v+1, mg.Up, mg.UpChunked); err != nil {
+			return fmt.Errorf("migration %s up: %w", m.name(v+1), err)
+		}
+	}
+	for v := current; v > version; v-- {
+		mg := m.migrations[v-1]
+		if err := m.step(false, v-1, mg.Down, mg.DownChunked); err != nil {
+			return fmt.Errorf("migration %s down: %w", m.name(v), err)
+		}
+	}
+	return nil
+}
+
+// name returns the identifier of the migration that upgrades the
+// database to the provided version.
+func (m *Migrator) name(version uint64) string {
+	if n := m.migrations[version-1].Name; n != "" {
+		return fmt.Sprintf("%d %q", version, n)
+	}
+	return fmt.Sprint(version)
+}
+
+// runStep runs a single migration step in the provided transaction.
+func runStep(tx *bolt.Tx, fn MigrateFunc, chunk ChunkFunc) (err error) {
+	if fn != nil {
+		return fn(tx)
+	}
+	var position []byte
+	for {
+		position, err = chunk(tx, position)
+		if err != nil {
+			return err
+		}
+		if len(position) == 0 {
+			return nil
+		}
+	}
+}
+
+// step runs a single migration step that sets the schema version to the
+// target version, in one transaction or, if it is chunked, in one
+// transaction for every chunk, saving the progress after each of them.
+func (m *Migrator) step(up bool, target uint64, fn MigrateFunc, chunk ChunkFunc) (err error) {
+	if fn != nil {
+		return m.db.Update(func(tx *bolt.Tx) error {
+			if schema := metaBucket(tx, schemaBucketName); schema != nil && schema.Get(schemaProgressKey) != nil {
+				return errProgress
+			}
+			if err := fn(tx); err != nil {
+				return err
+			}
+			return setSchemaVersion(tx, target)
+		})
+	}
+	prefix := make([]byte, 9)
+	if up {
+		prefix[0] = 1
+	}
+	binary.BigEndian.PutUint64(prefix[1:], target)
+	for done := false; !done; {
+		if err := m.db.Update(func(tx *bolt.Tx) error {
+			schema, err := createMetaBucket(tx, schemaBucketName)
+			if err != nil {
+				return err
+			}
+			var position []byte
+			if p := schema.Get(schemaProgressKey); p != nil {
+				if !bytes.HasPrefix(p, prefix) {
+					return errProgress
+				}
+				position = append([]byte(nil), p[len(prefix):]...)
+			}
+			next, err := chunk(tx, position)
+			if err != nil {
+				return err
+			}
+			if len(next) == 0 {
+				done = true
+				if err := schema.Delete(schemaProgressKey); err != nil {
+					return fmt.Errorf("bucket %s delete %s: %w", path(MetaBucketName, schemaBucketName), schemaProgressKey, err)
+				}
+				return setSchemaVersion(tx, target)
+			}
+			if err := schema.Put(schemaProgressKey, append(append([]byte(nil), prefix...), next...)); err != nil {
+				return fmt.Errorf("bucket %s put %s: %w", path(MetaBucketName, schemaBucketName), schemaProgressKey, err)
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SchemaVersion returns the schema version of the database set by
+// Migrator, or zero if no migrations were applied.
+func SchemaVersion(tx *bolt.Tx) (version uint64, err error) {
+	schema := metaBucket(tx, schemaBucketName)
+	if schema == nil {
+		return 0, nil
+	}
+	v := schema.Get(schemaVersionKey)
+	if v == nil {
+		return 0, nil
+	}
+	if len(v) != 8 {
+		return 0, fmt.Errorf("invalid schema version length %d", len(v))
+	}
+	return binary.BigEndian.Uint64(v), nil
+}
+
+func setSchemaVersion(tx *bolt.Tx, version uint64) (err error) {
+	schema, err := createMetaBucket(tx, schemaBucketName)
+	if err != nil {
+		return err
+	}
+	v := make([]byte, 8)
+	binary.BigEndian.PutUint64(v, version)
+	if err := schema.Put(schemaVersionKey, v); err != nil {
+		return fmt.Errorf("bucket %s put %s: %w", path(MetaBucketName, schemaBucketName), schemaVersionKey, err)
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+// Copyright (c) 2026, Janoš Guljaš <janos@resenje.org>
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package boltutils
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestMigrator(t *testing.T) {
+	db := newTestDB(t)
+	defer db.Destroy()
+
+	if err := db.DB.Update(func(tx *bolt.Tx) error {
+		for i := 0; i < 5; i++ {
+			if _, err := DeepPut(tx, false, []byte("users"), []byte(fmt.Sprintf("u%d", i)), []byte("name")); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("bolt db update transaction %s", err)
+	}
+
+	// copyChunk copies up to two keys from the users bucket to the
+	// people bucket in each call.
+	var chunks int
+	copyChunk := func(tx *bolt.Tx, position []byte) (next []byte, err error) {
+		chunks++
+		c := DeepBucket(tx, []byte("users")).Cursor()
+		k, v := c.First()
+		if position != nil {
+			k, v = c.Seek(position)
+		}
+		for i := 0; k != nil; k, v = c.Next() {
+			if i == 2 {
+				return append([]byte(nil), k...), nil
+			}
+			if _, err := DeepPut(tx, true, []byte("people"), k, v); err != nil {
+				return nil, err
+			}
+			i++
+		}
+		return nil, nil
+	}
+
+	migrations := []Migration{
+		{
+			Name: "rename users",
+			Up: func(tx *bolt.Tx) error {
+				return DeepRenameBucket(tx, NewPath([]byte("users")), []byte("accounts"))
+			},
+			Down: func(tx *bolt.Tx) error {
+				return DeepRenameBucket(tx, NewPath([]byte("accounts")), []byte("users"))
+			},
+		},
+		{
+			Name: "copy accounts",
+			UpChunked: func(tx *bolt.Tx, position []byte) (next []byte, err error) {
+				if position == nil {
+					if err := DeepRenameBucket(tx, NewPath([]byte("accounts")), []byte("users")); err != nil {
+						return nil, err
+					}
+				}
+				next, err = copyChunk(tx, position)
+				if err != nil || next != nil {
+					return next, err
+				}
+				return nil, DeepRenameBucket(tx, NewPath([]byte("users")), []byte("accounts"))
+			},
+			Down: func(tx *bolt.Tx) error {
+				return DeepDeleteBucket(tx, true, []byte("people"))
+			},
+		},
+	}
+
+	m := NewMigrator(db.DB, migrations...)
+	if v := m.Latest(); v != 2 {
+		t.Errorf("got latest version %d, want %d", v, 2)
+	}
+
+	version := func(want uint64) {
+		t.Helper()
+		v, err := m.Version()
+		if err != nil {
+			t.Fatalf("version: %s", err)
+		}
+		if v != want {
+			t.Errorf("got version %d, want %d", v, want)
+		}
+	}
+	buckets := func(want ...string) {
+		t.Helper()
+		var got []string
+		if err := db.DB.View(func(tx *bolt.Tx) error {
+			return tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+				got = append(got, string(name))
+				return nil
+			})
+		}); err != nil {
+			t.Fatalf("bolt db view transaction %s", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got buckets %q, want %q", got, want)
+		}
+	}
+
+	version(0)
+
+	if err := m.Migrate(&MigrateOptions{DryRun: true}); err != nil {
+		t.Fatalf("dry run: %s", err)
+	}
+	version(0)
+	buckets("users")
+	if chunks != 3 {
+		t.Errorf("got %d chunks, want %d", chunks, 3)
+	}
+
+	chunks = 0
+	if err := m.Migrate(nil); err != nil {
+		t.Fatalf("migrate: %s", err)
+	}
+	version(2)
+	buckets(string(MetaBucketName), "accounts", "people")
+	if chunks != 3 {
+		t.Errorf("got %d chunks, want %d", chunks, 3)
+	}
+	if got := dump(t, db, NewPath([]byte("people"))); len(got) != 5 {
+		t.Errorf("got %q", got)
+	}
+
+	if err := m.MigrateTo(0, nil); err != nil {
+		t.Fatalf("migrate down: %s", err)
+	}
+	version(0)
+	buckets(string(MetaBucketName), "users")
+
+	if err := m.MigrateTo(3, nil); err == nil {
+		t.Error("expected error")
+	}
+
+	t.Run("Resume", func(t *testing.T) {
+		errFail := errors.New("fail")
+		fail := true
+		m := NewMigrator(db.DB, migrations[0], Migration{
+			UpChunked: func(tx *bolt.Tx, position []byte) (next []byte, err error) {
+				if position != nil && fail {
+					return nil, errFail
+				}
+				return migrations[1].UpChunked(tx, position)
+			},
+		})
+		chunks = 0
+		if err := m.Migrate(nil); err == nil {
+			t.Fatal("expected error")
+		}
+		version(1)
+		if got := dump(t, db, NewPath([]byte("people"))); len(got) != 2 {
+			t.Errorf("got %q", got)
+		}
+
+		// Other steps can not be applied until the chunked step is
+		// completed.
+		if err := m.MigrateTo(0, nil); err == nil {
+			t.Error("expected error")
+		}
+		version(1)
+
+		fail = false
+		if err := m.Migrate(nil); err != nil {
+			t.Fatalf("migrate: %s", err)
+		}
+		version(2)
+		if chunks != 3 {
+			t.Errorf("got %d chunks, want %d", chunks, 3)
+		}
+		if got := dump(t, db, NewPath([]byte("people"))); len(got) != 5 {
+			t.Errorf("got %q", got)
+		}
+	})
+
+	t.Run("NoDown", func(t *testing.T) {
+		m := NewMigrator(db.DB, migrations[0], Migration{UpChunked: migrations[1].UpChunked})
+		if err := m.MigrateTo(0, nil); err == nil {
+			t.Error("expected error")
+		}
+		version(2)
+	})
+
+	t.Run("NewerDatabase", func(t *testing.T) {
+		m := NewMigrator(db.DB, migrations[0])
+		_, err := m.Version()
+		var e *VersionError
+		if !errors.As(err, &e) {
+			t.Fatalf("invalid error: %v", err)
+		}
+		if e.Version != 2 || e.Latest != 1 {
+			t.Errorf("got %+v", e)
+		}
+		if err := m.Migrate(nil); !errors.As(err, &e) {
+			t.Errorf("invalid error: %v", err)
+		}
+	})
+}
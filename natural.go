@@ -7,8 +7,14 @@ package boltutils
 
 import (
 	"encoding/binary"
+	"fmt"
 	"strconv"
+	"strings"
 	"unicode"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+	"golang.org/x/text/unicode/norm"
 )
 
 // Natural returns a string suitable for natural sort ordering.
@@ -60,3 +66,306 @@ func getFirstNumber(in string) (start, end int, number uint64) {
 	}
 	return start, end, number
 }
+
+// NaturalReversible returns a string suitable for natural sort ordering
+// that can be decoded to the original string with NaturalDecode. Every
+// run of ASCII digits is encoded as a number of arbitrary size, ordered
+// by its value, and the number of its leading zeros is preserved.
+// Strings that differ only in leading zeros of numbers are ordered by
+// the numbers of leading zeros, so "7" is sorted before "07", but "07"
+// is sorted before "7a". Numbers are sorted before all other
+// characters, except bytes 0x00 and 0x01.
+func NaturalReversible(in string) (out string) {
+	return string(AppendNaturalReversible(nil, []byte(in)))
+}
+
+// NaturalReversibleBytes returns the encoding of the byte slice as
+// described in NaturalReversible.
+func NaturalReversibleBytes(in []byte) (out []byte) {
+	return AppendNaturalReversible(nil, in)
+}
+
+// AppendNaturalReversible appends the encoding of in, as described in
+// NaturalReversible, to dst and returns the extended slice.
+//
+// Numbers are encoded without leading zeros. If any number has leading
+// zeros, the encoding ends with the trailer that holds the numbers of
+// leading zeros of all numbers, in order, so that they are compared only
+// if the rest of the encoding is equal.
+func AppendNaturalReversible(dst, in []byte) (out []byte) {
+	out = dst
+	var zeros []uint64
+	leading := false
+	for i := 0; i < len(in); {
+		c := in[i]
+		if !isASCIIDigit(c) {
+			switch c {
+			case 0x00:
+				out = append(out, 0x00, 0xff)
+			case naturalNumberMark:
+				out = append(out, naturalNumberMark, 0x00)
+			default:
+				out = append(out, c)
+			}
+			i++
+			continue
+		}
+		start := i
+		for i < len(in) && isASCIIDigit(in[i]) {
+			i++
+		}
+		z := start
+		for z < i && in[z] == '0' {
+			z++
+		}
+		digits := in[z:i]
+		out = append(out, naturalNumberMark)
+		out = appendNaturalLength(out, uint64(len(digits))+1)
+		out = append(out, digits...)
+		zeros = append(zeros, uint64(z-start))
+		if z > start {
+			leading = true
+		}
+	}
+	if !leading {
+		return out
+	}
+	out = append(out, naturalTrailerMark...)
+	for _, z := range zeros {
+		out = appendNaturalLength(out, z)
+	}
+	return out
+}
+
+// NaturalDecode returns the original string encoded by
+// NaturalReversible.
+func NaturalDecode(in string) (out string, err error) {
+	b, err := NaturalDecodeBytes([]byte(in))
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// NaturalDecodeBytes returns the original byte slice encoded by
+// NaturalReversibleBytes. It returns an error if the total number of
+// leading zeros of all numbers is more than 256 times the length of
+// the encoded input.
+func NaturalDecodeBytes(in []byte) (out []byte, err error) {
+	out = make([]byte, 0, len(in))
+	// numbers holds positions in the input and in out of decoded
+	// numbers and their lengths without leading zeros.
+	var numbers []naturalDecodedNumber
+	number := false // true if the last decoded token is a number
+	trailer := -1   // position of the leading zeros counts in the input
+	for i := 0; i < len(in) && trailer < 0; {
+		c := in[i]
+		switch {
+		case c == 0x00:
+			switch {
+			case i+1 < len(in) && in[i+1] == 0xff:
+				out = append(out, 0x00)
+			case i+1 < len(in) && in[i+1] == 0x00:
+				trailer = i + 2
+			default:
+				return nil, fmt.Errorf("invalid natural encoding: invalid zero byte escape at %d", i)
+			}
+			i += 2
+			number = false
+		case c == naturalNumberMark && i+1 < len(in) && in[i+1] == 0x00:
+			out = append(out, naturalNumberMark)
+			i += 2
+			number = false
+		case c == naturalNumberMark:
+			if number {
+				return nil, fmt.Errorf("invalid natural encoding: consecutive numbers at %d", i)
+			}
+			start := i
+			l, n, ok := readNaturalLength(in[i+1:])
+			if !ok || l == 0 {
+				return nil, fmt.Errorf("invalid natural encoding: invalid number length at %d", start)
+			}
+			i += 1 + n
+			l--
+			if l > uint64(len(in)-i) {
+				return nil, fmt.Errorf("invalid natural encoding: short number at %d", start)
+			}
+			digits := in[i : i+int(l)]
+			for j, d := range digits {
+				if !isASCIIDigit(d) || (j == 0 && d == '0') {
+					return nil, fmt.Errorf("invalid natural encoding: invalid digit at %d", i+j)
+				}
+			}
+			i += int(l)
+			numbers = append(numbers, naturalDecodedNumber{start: start, at: len(out), length: len(digits)})
+			out = append(out, digits...)
+			number = true
+		case isASCIIDigit(c):
+			return nil, fmt.Errorf("invalid natural encoding: unexpected digit at %d", i)
+		default:
+			out = append(out, c)
+			i++
+			number = false
+		}
+	}
+	if trailer < 0 {
+		for _, num := range numbers {
+			if num.length == 0 {
+				return nil, fmt.Errorf("invalid natural encoding: missing leading zeros count at %d", num.start)
+			}
+		}
+		return out, nil
+	}
+	maxZeros := uint64(len(in)) * maxNaturalZerosPerByte
+	zeros := make([]uint64, len(numbers))
+	var total uint64
+	i := trailer
+	for j, num := range numbers {
+		z, n, ok := readNaturalLength(in[i:])
+		if !ok || (num.length == 0 && z == 0) {
+			return nil, fmt.Errorf("invalid natural encoding: invalid leading zeros count at %d", i)
+		}
+		if z > maxZeros {
+			return nil, fmt.Errorf("invalid natural encoding: too many leading zeros at %d", i)
+		}
+		maxZeros -= z
+		total += z
+		zeros[j] = z
+		i += n
+	}
+	if i != len(in) {
+		return nil, fmt.Errorf("invalid natural encoding: unexpected data after leading zeros counts at %d", i)
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("invalid natural encoding: no leading zeros at %d", trailer)
+	}
+	decoded := make([]byte, 0, uint64(len(out))+total)
+	prev := 0
+	for j, num := range numbers {
+		decoded = append(decoded, out[prev:num.at]...)
+		for z := uint64(0); z < zeros[j]; z++ {
+			decoded = append(decoded, '0')
+		}
+		prev = num.at
+	}
+	return append(decoded, out[prev:]...), nil
+}
+
+// naturalDecodedNumber is a number decoded by NaturalDecodeBytes before
+// its leading zeros are restored.
+type naturalDecodedNumber struct {
+	// start is the position of the number in the encoded input.
+	start int
+	// at is the position of the number in the decoded output.
+	at int
+	// length is the number of digits without leading zeros.
+	length int
+}
+
+// naturalNumberMark is the byte that starts an encoded number in
+// NaturalReversible encoding.
+const naturalNumberMark = 0x01
+
+// naturalTrailerMark separates numbers of leading zeros from the rest of
+// NaturalReversible encoding. It is sorted before every escaped zero byte
+// and every other byte, so that the trailer is never compared with the
+// rest of the encoding.
+var naturalTrailerMark = []byte{0x00, 0x00}
+
+// maxNaturalZerosPerByte limits the total number of leading zeros that
+// are decoded to this many times the length of the encoded input, to
+// protect from allocating large amounts of memory on invalid input.
+const maxNaturalZerosPerByte = 256
+
+// appendNaturalLength appends the length as a single byte if it is less
+// than 0xff, and as 0xff followed by 8 bytes of the big endian length
+// otherwise. The encoding preserves the order of lengths.
+func appendNaturalLength(dst []byte, l uint64) []byte {
+	if l < 0xff {
+		return append(dst, byte(l))
+	}
+	b := make([]byte, 9)
+	b[0] = 0xff
+	binary.BigEndian.PutUint64(b[1:], l)
+	return append(dst, b...)
+}
+
+func readNaturalLength(in []byte) (l uint64, n int, ok bool) {
+	if len(in) < 1 {
+		return 0, 0, false
+	}
+	if in[0] < 0xff {
+		return uint64(in[0]), 1, true
+	}
+	if len(in) < 9 {
+		return 0, 0, false
+	}
+	l = binary.BigEndian.Uint64(in[1:9])
+	if l < 0xff {
+		return 0, 0, false
+	}
+	return l, 9, true
+}
+
+func isASCIIDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// NaturalOptions holds optional parameters for NaturalWithOptions.
+type NaturalOptions struct {
+	// UnicodeDigits makes digits of all scripts, and not only ASCII
+	// digits, to be encoded as numbers.
+	UnicodeDigits bool
+	// FoldCase makes the encoding case-insensitive by applying Unicode
+	// case folding, or lowercasing rules of the Language if it is set.
+	FoldCase bool
+	// Language is used for language specific case folding, such as of
+	// the Turkish dotted and dotless I.
+	Language language.Tag
+	// NormalizeNFC normalizes the input to Unicode Normalization Form C,
+	// so that canonically equivalent strings have the same encoding.
+	NormalizeNFC bool
+	// IgnorePunctuation removes all Unicode punctuation characters
+	// from the input.
+	IgnorePunctuation bool
+}
+
+// NaturalWithOptions returns a string suitable for natural sort ordering
+// as Natural does, after the input is transformed according to the
+// options. Encoded strings are byte-comparable and can be used as Bolt
+// keys, but they can not be decoded.
+func NaturalWithOptions(in string, o NaturalOptions) (out string) {
+	if o.NormalizeNFC {
+		in = norm.NFC.String(in)
+	}
+	if o.FoldCase {
+		if o.Language == language.Und {
+			in = cases.Fold().String(in)
+		} else {
+			in = cases.Lower(o.Language).String(in)
+		}
+	}
+	if o.UnicodeDigits || o.IgnorePunctuation {
+		in = strings.Map(func(r rune) rune {
+			if o.IgnorePunctuation && unicode.IsPunct(r) {
+				return -1
+			}
+			if o.UnicodeDigits && r > unicode.MaxASCII && unicode.IsDigit(r) {
+				return '0' + digitValue(r)
+			}
+			return r
+		}, in)
+	}
+	return Natural(in)
+}
+
+// digitValue returns the numeric value of a Unicode decimal digit. All
+// decimal digits are in contiguous ranges that start with digit zero,
+// some of them with multiple consecutive sets of ten digits.
+func digitValue(r rune) rune {
+	zero := r
+	for unicode.IsDigit(zero - 1) {
+		zero--
+	}
+	return (r - zero) % 10
+}
@@ -8,7 +8,9 @@ package boltutils_test
 import (
 	"math"
 	"strconv"
+	"strings"
 	"testing"
+	"unicode"
 
 	"resenje.org/boltutils"
 )
@@ -64,3 +66,278 @@ func TestNatural(t *testing.T) {
 		}
 	}
 }
+
+func TestNaturalReversible(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"a",
+		"0",
+		"00",
+		"7",
+		"007",
+		"a1",
+		"1a",
+		"Go 1.11rc1 rocks",
+		"\x00\x01\x02\xff",
+		"a\x0112\x00",
+		strconv.FormatUint(math.MaxUint64, 10) + "1",
+		"340282366920938463463374607431768211455",
+		strings.Repeat("9", 300),
+		strings.Repeat("0", 300) + "1",
+		strings.Repeat("0", 2000),
+	} {
+		out := boltutils.NaturalReversible(in)
+		got, err := boltutils.NaturalDecode(out)
+		if err != nil {
+			t.Errorf("%q: decode %q: %s", in, out, err)
+			continue
+		}
+		if got != in {
+			t.Errorf("%q: decoded %q", in, got)
+		}
+		b, err := boltutils.NaturalDecodeBytes(boltutils.NaturalReversibleBytes([]byte(in)))
+		if err != nil {
+			t.Errorf("%q: decode bytes: %s", in, err)
+			continue
+		}
+		if string(b) != in {
+			t.Errorf("%q: decoded bytes %q", in, b)
+		}
+	}
+}
+
+func TestNaturalReversibleOrder(t *testing.T) {
+	// Strings in ascending natural order.
+	ordered := []string{
+		"",
+		"\x00",
+		"\x01",
+		"0",
+		"00",
+		"1",
+		"01",
+		"2",
+		"9",
+		"10",
+		"99",
+		"100",
+		strconv.FormatUint(math.MaxUint64, 10),
+		strconv.FormatUint(math.MaxUint64, 10) + "0",
+		strings.Repeat("9", 300),
+		"1" + strings.Repeat("0", 300),
+		"a",
+		"a1",
+		"a2",
+		"a07b",
+		"a7c",
+		"a10",
+		"a10b",
+		"b",
+		"file07",
+		"file7.txt",
+		"file07.txt",
+		"file8",
+	}
+	var prev string
+	for i, in := range ordered {
+		out := boltutils.NaturalReversible(in)
+		if i > 0 && prev >= out {
+			t.Errorf("%q: encoding %q is not greater than %q of %q", in, out, prev, ordered[i-1])
+		}
+		prev = out
+	}
+}
+
+func TestNaturalDecodeError(t *testing.T) {
+	for _, in := range []string{
+		"\x00",
+		"\x00a",
+		"1",
+		"\x01",
+		"\x01\x02",
+		"\x01\x02a\x00",
+		"\x01\x030",
+		"\x01\x01",
+		"\x01\x01\x00",
+		"\x01\x021\x01\x021",
+		// Invalid trailers with leading zeros counts.
+		"a\x00\x00",
+		"\x01\x021\x00\x00",
+		"\x01\x021\x00\x00\x00",
+		"\x01\x01\x00\x00\x00",
+		"\x01\x021\x00\x00\x01\x00",
+		"\x01\x021a\x01\x022\x00\x00\x01",
+		// Leading zeros count that is too large for the input length.
+		"\x01\x01\x00\x00\xff\x00\x00\x00\x00\x40\x00\x00\x00",
+		"\x01\x01\x00\x00\xff\xff\xff\xff\xff\xff\xff\xff\xff",
+	} {
+		if out, err := boltutils.NaturalDecode(in); err == nil {
+			t.Errorf("%q: expected error, got %q", in, out)
+		}
+	}
+}
+
+func TestNaturalWithOptions(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		a, b string
+		opts boltutils.NaturalOptions
+	}{
+		{
+			name: "UnicodeDigits",
+			a:    "file٢", // Arabic-Indic two
+			b:    "file10",
+			opts: boltutils.NaturalOptions{UnicodeDigits: true},
+		},
+		{
+			name: "FullwidthDigits",
+			a:    "file９",
+			b:    "file１０",
+			opts: boltutils.NaturalOptions{UnicodeDigits: true},
+		},
+		{
+			name: "FoldCase",
+			a:    "a10",
+			b:    "B2",
+			opts: boltutils.NaturalOptions{FoldCase: true},
+		},
+		{
+			name: "IgnorePunctuation",
+			a:    "a-2",
+			b:    "a1.0",
+			opts: boltutils.NaturalOptions{IgnorePunctuation: true},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			a := boltutils.NaturalWithOptions(tc.a, tc.opts)
+			b := boltutils.NaturalWithOptions(tc.b, tc.opts)
+			if a >= b {
+				t.Errorf("%q (%q) is not sorted before %q (%q)", tc.a, a, tc.b, b)
+			}
+			// Without options, the order is different.
+			if boltutils.Natural(tc.a) < boltutils.Natural(tc.b) {
+				t.Errorf("%q is sorted before %q without options", tc.a, tc.b)
+			}
+		})
+	}
+
+	for _, tc := range []struct {
+		name string
+		a, b string
+		opts boltutils.NaturalOptions
+	}{
+		{
+			name: "UnicodeDigitsEqual",
+			a:    "file٢٣",
+			b:    "file23",
+			opts: boltutils.NaturalOptions{UnicodeDigits: true},
+		},
+		{
+			name: "MathematicalDigits",
+			a:    "\U0001D7D7", // mathematical double-struck digit nine
+			b:    "9",
+			opts: boltutils.NaturalOptions{UnicodeDigits: true},
+		},
+		{
+			name: "FoldCaseEqual",
+			a:    "Straße 12",
+			b:    "STRASSE 12",
+			opts: boltutils.NaturalOptions{FoldCase: true},
+		},
+		{
+			name: "NormalizeNFC",
+			a:    "\u00e9",
+			b:    "e\u0301",
+			opts: boltutils.NaturalOptions{NormalizeNFC: true},
+		},
+		{
+			name: "IgnorePunctuationEqual",
+			a:    "a.b,c!1",
+			b:    "abc1",
+			opts: boltutils.NaturalOptions{IgnorePunctuation: true},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			a := boltutils.NaturalWithOptions(tc.a, tc.opts)
+			b := boltutils.NaturalWithOptions(tc.b, tc.opts)
+			if a != b {
+				t.Errorf("%q (%q) is not equal to %q (%q)", tc.a, a, tc.b, b)
+			}
+		})
+	}
+}
+
+func FuzzNaturalReversible(f *testing.F) {
+	f.Add("")
+	f.Add("Go 1.11rc1 rocks")
+	f.Add("007")
+	f.Add("\x00\x01\x02")
+	f.Add("340282366920938463463374607431768211455")
+	f.Fuzz(func(t *testing.T, in string) {
+		out := boltutils.NaturalReversible(in)
+		got, err := boltutils.NaturalDecode(out)
+		if err != nil {
+			t.Fatalf("%q: decode %q: %s", in, out, err)
+		}
+		if got != in {
+			t.Errorf("%q: decoded %q", in, got)
+		}
+	})
+}
+
+func FuzzNaturalOrder(f *testing.F) {
+	f.Add("file", uint64(2), uint8(0), ".txt", uint64(10), uint8(0), ".txt")
+	f.Add("", uint64(0), uint8(0), "", uint64(math.MaxUint64), uint8(0), "")
+	f.Add("a\x00", uint64(9), uint8(0), "b", uint64(9), uint8(0), "b")
+	f.Add("a", uint64(7), uint8(1), "b", uint64(7), uint8(0), "c")
+	f.Add("file", uint64(7), uint8(0), ".txt", uint64(7), uint8(1), "")
+	f.Add("", uint64(0), uint8(2), "\x00", uint64(0), uint8(1), "\x01")
+	f.Fuzz(func(t *testing.T, prefix string, a uint64, zerosA uint8, suffixA string, b uint64, zerosB uint8, suffixB string) {
+		for _, s := range []string{prefix, suffixA, suffixB} {
+			if strings.IndexFunc(s, unicode.IsDigit) >= 0 {
+				return
+			}
+		}
+		sa := prefix + strings.Repeat("0", int(zerosA)) + strconv.FormatUint(a, 10) + suffixA
+		sb := prefix + strings.Repeat("0", int(zerosB)) + strconv.FormatUint(b, 10) + suffixB
+		// Numbers are compared by value, then the rest of the strings,
+		// and only then the numbers of leading zeros.
+		want := compareUint64(a, b)
+		if want == 0 {
+			want = strings.Compare(suffixA, suffixB)
+		}
+		if got := strings.Compare(boltutils.Natural(sa), boltutils.Natural(sb)); got != want {
+			t.Errorf("%q and %q: got natural comparison %d, want %d", sa, sb, got, want)
+		}
+		if want == 0 {
+			want = compareUint64(uint64(zerosA), uint64(zerosB))
+		}
+		if got := strings.Compare(boltutils.NaturalReversible(sa), boltutils.NaturalReversible(sb)); got != want {
+			t.Errorf("%q and %q: got reversible natural comparison %d, want %d", sa, sb, got, want)
+		}
+	})
+}
+
+func compareUint64(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func FuzzNaturalDecode(f *testing.F) {
+	f.Add([]byte(boltutils.NaturalReversible("Go 1.11rc1 rocks")))
+	f.Add([]byte{0x01, 0xff, 0, 0, 0, 0, 0, 0, 0, 0xff})
+	f.Fuzz(func(t *testing.T, in []byte) {
+		out, err := boltutils.NaturalDecodeBytes(in)
+		if err != nil {
+			return
+		}
+		if got := boltutils.NaturalReversibleBytes(out); string(got) != string(in) {
+			t.Errorf("%q: decoded %q encodes to %q", in, out, got)
+		}
+	})
+}
@@ -0,0 +1,375 @@
+// Copyright (c) 2026, Janoš Guljaš <janos@resenje.org>
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package boltutils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// ErrSlowSubscriber is returned by Subscription.Err if the subscription
+// is closed by the Disconnect policy.
+var ErrSlowSubscriber = errors.New("slow subscriber")
+
+// Event is a change made with DB methods that is sent to subscribers
+// after the transaction is committed. Slices in the event are shared
+// between subscribers and must not be modified.
+type Event struct {
+	// Op is OpPut, OpDelete or OpDeleteBucket.
+	Op Op
+	// Path references the changed key, or the deleted bucket for
+	// OpDeleteBucket.
+	Path Path
+	// OldValue is the value before the change, or nil if the key did
+	// not exist. It is always nil for OpDeleteBucket.
+	OldValue []byte
+	// NewValue is the value after the change, or nil if the key is
+	// deleted.
+	NewValue []byte
+}
+
+// SlowSubscriberPolicy defines what happens with an event for a
+// subscriber whose buffer is full.
+type SlowSubscriberPolicy int
+
+const (
+	// DropNewest discards the event that does not fit in the buffer.
+	DropNewest SlowSubscriberPolicy = iota
+	// DropOldest discards the oldest buffered event to make room for
+	// the new one.
+	DropOldest
+	// Block waits until there is room in the buffer. It blocks the
+	// goroutine that committed the transaction and delivery of events
+	// to all other subscribers. Other writes are not blocked, but a
+	// subscriber must not wait for its own writes that it receives
+	// events for, as it would wait for itself to read the events.
+	Block
+	// Disconnect closes the subscription and Err returns
+	// ErrSlowSubscriber.
+	Disconnect
+)
+
+// SubscribeOptions holds optional parameters for DB.Subscribe.
+type SubscribeOptions struct {
+	// BufferSize is the capacity of the events channel. Default is 64.
+	BufferSize int
+	// Policy is applied when the buffer is full. Default is DropNewest.
+	Policy SlowSubscriberPolicy
+}
+
+// Subscription receives events for changes under a path prefix.
+type Subscription struct {
+	dropped uint64 // accessed atomically, first for alignment
+
+	db     *DB
+	prefix Path
+	policy SlowSubscriberPolicy
+	events chan Event
+	done   chan struct{}
+	once   sync.Once
+
+	// sendMu serializes sending to and closing of the events channel.
+	sendMu sync.Mutex
+	// mu protects closed and err.
+	mu     sync.Mutex
+	closed bool
+	err    error
+}
+
+// Subscribe returns a Subscription to changes made with DB methods to
+// keys in the bucket referenced by the prefix path and its nested
+// buckets. If the prefix has a key, only changes to keys in the same
+// bucket that start with the prefix key are sent. Deletion of a bucket
+// is sent to all subscriptions with prefixes in that bucket. Events of
+// a single transaction are sent in the order in which changes are made.
+func (d *DB) Subscribe(prefix Path, opts *SubscribeOptions) (s *Subscription) {
+	if opts == nil {
+		opts = new(SubscribeOptions)
+	}
+	size := opts.BufferSize
+	if size <= 0 {
+		size = 64
+	}
+	s = &Subscription{
+		db:     d,
+		prefix: copyPath(prefix),
+		policy: opts.Policy,
+		events: make(chan Event, size),
+		done:   make(chan struct{}),
+	}
+	d.mu.Lock()
+	d.subscriptions = append(d.subscriptions, s)
+	atomic.AddInt32(&d.subscribers, 1)
+	d.mu.Unlock()
+	return s
+}
+
+// Events returns the channel on which events are received. It is closed
+// when the subscription is closed.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Dropped returns the number of events discarded because the buffer was
+// full.
+func (s *Subscription) Dropped() (n uint64) {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+// Err returns ErrSlowSubscriber if the subscription is closed by the
+// Disconnect policy.
+func (s *Subscription) Err() (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Close stops sending events and closes the events channel.
+func (s *Subscription) Close() {
+	// Unblock the publisher that may wait to send with Block policy.
+	s.once.Do(func() { close(s.done) })
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+	s.close(nil)
+}
+
+// close closes the events channel and removes the subscription from the
+// DB. It must be called with sendMu held.
+func (s *Subscription) close(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	s.err = err
+	close(s.events)
+
+	d := s.db
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for i, e := range d.subscriptions {
+		if e == s {
+			d.subscriptions = append(d.subscriptions[:i], d.subscriptions[i+1:]...)
+			atomic.AddInt32(&d.subscribers, -1)
+			break
+		}
+	}
+}
+
+func (s *Subscription) match(e Event) bool {
+	if e.Op == OpDeleteBucket && s.prefix.HasPrefix(e.Path) {
+		return true
+	}
+	if !e.Path.HasPrefix(s.prefix) {
+		return false
+	}
+	if s.prefix.HasKey() {
+		return len(e.Path.Buckets) == len(s.prefix.Buckets) && bytes.HasPrefix(e.Path.Key, s.prefix.Key)
+	}
+	return true
+}
+
+// publish sends the event to all matching subscriptions. The DB lock is
+// not held while sending, so that subscribers can write to the DB.
+func (d *DB) publish(e Event) {
+	d.mu.Lock()
+	subscriptions := append([]*Subscription(nil), d.subscriptions...)
+	d.mu.Unlock()
+	for _, s := range subscriptions {
+		if s.match(e) {
+			s.send(e)
+		}
+	}
+}
+
+func (s *Subscription) send(e Event) {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+	s.mu.Lock()
+	closed := s.closed
+	s.mu.Unlock()
+	if closed {
+		return
+	}
+	select {
+	case s.events <- e:
+		return
+	default:
+	}
+	switch s.policy {
+	case DropOldest:
+		select {
+		case <-s.events:
+			atomic.AddUint64(&s.dropped, 1)
+		default:
+		}
+		select {
+		case s.events <- e:
+		default:
+			atomic.AddUint64(&s.dropped, 1)
+		}
+	case Block:
+		select {
+		case s.events <- e:
+		case <-s.done:
+		}
+	case Disconnect:
+		s.close(ErrSlowSubscriber)
+	default:
+		atomic.AddUint64(&s.dropped, 1)
+	}
+}
+
+// watched returns true if there are subscriptions to which events
+// should be sent.
+func (d *DB) watched() bool {
+	return atomic.LoadInt32(&d.subscribers) > 0
+}
+
+// record sends the event to subscribers after the transaction is
+// committed.
+func (d *DB) record(tx *bolt.Tx, e Event) {
+	e.Path = copyPath(e.Path)
+	e.NewValue = copyBytes(e.NewValue)
+	tx.OnCommit(func() {
+		d.publish(e)
+	})
+}
+
+// DeepPut saves the value in the same way as the DeepPut function,
+// records the change in the journal if it is enabled, and sends it to
+// subscribers after the transaction is committed.
+func (d *DB) DeepPut(tx *bolt.Tx, overwrite bool, elements ...[]byte) (new bool, err error) {
+	length := len(elements)
+	if length < 3 {
+		return false, fmt.Errorf("insufficient number of elements %d < 3", length)
+	}
+	return d.pathPut(tx, overwrite, NewKeyPath(elements[length-2], elements[:length-2]...), elements[length-1], time.Time{})
+}
+
+// DeepDelete deletes the key in the same way as the DeepDelete function,
+// records the change in the journal if it is enabled, and sends it to
+// subscribers after the transaction is committed.
+func (d *DB) DeepDelete(tx *bolt.Tx, ensure bool, elements ...[]byte) (err error) {
+	length := len(elements)
+	if length < 2 {
+		return fmt.Errorf("insufficient number of elements %d < 2", length)
+	}
+	return d.pathDelete(tx, ensure, NewKeyPath(elements[length-1], elements[:length-1]...))
+}
+
+// DeepDeleteBucket deletes the bucket in the same way as the
+// DeepDeleteBucket function, records the change in the journal if it is
+// enabled, and sends it to subscribers after the transaction is
+// committed.
+func (d *DB) DeepDeleteBucket(tx *bolt.Tx, ensure bool, elements ...[]byte) (err error) {
+	length := len(elements)
+	if length < 1 {
+		return fmt.Errorf("insufficient number of elements %d < 1", length)
+	}
+	return d.pathDeleteBucket(tx, ensure, NewPath(elements...))
+}
+
+// pathPut saves the value in the same way as PathPut, or as
+// PathPutWithExpiry if expires is not zero, and records the change in
+// the journal and for subscribers.
+func (d *DB) pathPut(tx *bolt.Tx, overwrite bool, p Path, value []byte, expires time.Time) (new bool, err error) {
+	watched := d.watched()
+	var old []byte
+	if watched {
+		old = copyBytes(PathGet(tx, p))
+	}
+	if expires.IsZero() {
+		new, err = PathPut(tx, overwrite, p, value)
+	} else {
+		new, err = PathPutWithExpiry(tx, overwrite, p, value, expires)
+	}
+	if err != nil {
+		return new, err
+	}
+	if d.journal {
+		if err := writeJournal(tx, JournalEntry{Op: OpPut, Path: p, Value: value, Expires: expires}); err != nil {
+			return new, err
+		}
+	}
+	if watched {
+		d.record(tx, Event{Op: OpPut, Path: p, OldValue: old, NewValue: value})
+	}
+	return new, nil
+}
+
+// pathDelete deletes the key in the same way as PathDelete and records
+// the change in the journal and for subscribers if the key existed.
+func (d *DB) pathDelete(tx *bolt.Tx, ensure bool, p Path) (err error) {
+	watched := d.watched()
+	var old []byte
+	if watched || d.journal {
+		old = copyBytes(PathGet(tx, p))
+	}
+	if err = PathDelete(tx, ensure, p); err != nil {
+		return err
+	}
+	if old == nil {
+		return nil
+	}
+	if d.journal {
+		if err := writeJournal(tx, JournalEntry{Op: OpDelete, Path: p}); err != nil {
+			return err
+		}
+	}
+	if watched {
+		d.record(tx, Event{Op: OpDelete, Path: p, OldValue: old})
+	}
+	return nil
+}
+
+// pathDeleteBucket deletes the bucket in the same way as
+// PathDeleteBucket and records the change in the journal and for
+// subscribers if the bucket existed.
+func (d *DB) pathDeleteBucket(tx *bolt.Tx, ensure bool, p Path) (err error) {
+	watched := d.watched()
+	exists := (watched || d.journal) && PathBucket(tx, p) != nil
+	if err = PathDeleteBucket(tx, ensure, p); err != nil {
+		return err
+	}
+	if !exists {
+		return nil
+	}
+	if d.journal {
+		if err := writeJournal(tx, JournalEntry{Op: OpDeleteBucket, Path: p.Bucket()}); err != nil {
+			return err
+		}
+	}
+	if watched {
+		d.record(tx, Event{Op: OpDeleteBucket, Path: p.Bucket()})
+	}
+	return nil
+}
+
+func copyPath(p Path) Path {
+	c := Path{Key: copyBytes(p.Key)}
+	if p.Buckets != nil {
+		c.Buckets = make([][]byte, len(p.Buckets))
+		for i, b := range p.Buckets {
+			c.Buckets[i] = copyBytes(b)
+		}
+	}
+	return c
+}
+
+func copyBytes(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	return append(make([]byte, 0, len(b)), b...)
+}
@@ -0,0 +1,280 @@
+// Copyright (c) 2026, Janoš Guljaš <janos@resenje.org>
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package boltutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestSubscribe(t *testing.T) {
+	tdb := newTestDB(t)
+	defer tdb.Destroy()
+
+	db := NewDB(tdb.DB, nil)
+	ctx := context.Background()
+
+	all := db.Subscribe(NewPath(), nil)
+	defer all.Close()
+	users := db.Subscribe(NewPath([]byte("users")), nil)
+	defer users.Close()
+	emails := db.Subscribe(NewKeyPath([]byte("e"), []byte("users"), []byte("contacts")), nil)
+	defer emails.Close()
+
+	if err := db.Update(ctx, func(tx *bolt.Tx) error {
+		if _, err := db.DeepPut(tx, true, []byte("users"), []byte("contacts"), []byte("email"), []byte("a@b")); err != nil {
+			return err
+		}
+		if _, err := db.DeepPut(tx, true, []byte("users"), []byte("contacts"), []byte("phone"), []byte("123")); err != nil {
+			return err
+		}
+		if _, err := db.DeepPut(tx, true, []byte("groups"), []byte("admins"), []byte("1")); err != nil {
+			return err
+		}
+		// Deletion of a missing key is not a change.
+		if err := db.DeepDelete(tx, false, []byte("groups"), []byte("missing")); err != nil {
+			return err
+		}
+		if len(all.Events()) != 0 {
+			t.Error("events sent before commit")
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("bolt db update transaction %s", err)
+	}
+	if _, err := db.Put(ctx, NewKeyPath([]byte("email"), []byte("users"), []byte("contacts")), []byte("c@d"), nil); err != nil {
+		t.Fatalf("put: %s", err)
+	}
+	if err := db.Delete(ctx, true, NewKeyPath([]byte("phone"), []byte("users"), []byte("contacts"))); err != nil {
+		t.Fatalf("delete: %s", err)
+	}
+	if err := db.DeleteBucket(ctx, true, NewPath([]byte("users"))); err != nil {
+		t.Fatalf("delete bucket: %s", err)
+	}
+
+	// Changes of rolled back transactions are not sent.
+	errRollback := errors.New("rollback")
+	if err := db.Update(ctx, func(tx *bolt.Tx) error {
+		if _, err := db.DeepPut(tx, true, []byte("users"), []byte("x"), []byte("y")); err != nil {
+			return err
+		}
+		return errRollback
+	}); err != errRollback {
+		t.Fatalf("invalid error: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name string
+		s    *Subscription
+		want []string
+	}{
+		{
+			name: "all",
+			s:    all,
+			want: []string{
+				`put users/contacts/email "" "a@b"`,
+				`put users/contacts/phone "" "123"`,
+				`put groups/admins "" "1"`,
+				`put users/contacts/email "a@b" "c@d"`,
+				`delete users/contacts/phone "123" ""`,
+				`delete bucket users "" ""`,
+			},
+		},
+		{
+			name: "users",
+			s:    users,
+			want: []string{
+				`put users/contacts/email "" "a@b"`,
+				`put users/contacts/phone "" "123"`,
+				`put users/contacts/email "a@b" "c@d"`,
+				`delete users/contacts/phone "123" ""`,
+				`delete bucket users "" ""`,
+			},
+		},
+		{
+			name: "emails",
+			s:    emails,
+			want: []string{
+				`put users/contacts/email "" "a@b"`,
+				`put users/contacts/email "a@b" "c@d"`,
+				`delete bucket users "" ""`,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := receiveEvents(tc.s)
+			if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+				t.Errorf("got events %q, want %q", got, tc.want)
+			}
+			if n := tc.s.Dropped(); n != 0 {
+				t.Errorf("got %d dropped events", n)
+			}
+		})
+	}
+
+	all.Close()
+	all.Close()
+	if _, ok := <-all.Events(); ok {
+		t.Error("events channel not closed")
+	}
+	if err := all.Err(); err != nil {
+		t.Errorf("got error %v", err)
+	}
+}
+
+func TestSubscribePolicy(t *testing.T) {
+	tdb := newTestDB(t)
+	defer tdb.Destroy()
+
+	db := NewDB(tdb.DB, nil)
+	ctx := context.Background()
+
+	put := func(value string) {
+		t.Helper()
+		if _, err := db.Put(ctx, NewKeyPath([]byte("k"), []byte("b")), []byte(value), nil); err != nil {
+			t.Fatalf("put: %s", err)
+		}
+	}
+
+	t.Run("DropNewest", func(t *testing.T) {
+		s := db.Subscribe(NewPath(), &SubscribeOptions{BufferSize: 2})
+		defer s.Close()
+		for _, v := range []string{"1", "2", "3"} {
+			put(v)
+		}
+		if n := s.Dropped(); n != 1 {
+			t.Errorf("got %d dropped events, want %d", n, 1)
+		}
+		if got := fmt.Sprint(receiveEvents(s)); got != `[put b/k "" "1" put b/k "1" "2"]` {
+			t.Errorf("got events %s", got)
+		}
+	})
+
+	t.Run("DropOldest", func(t *testing.T) {
+		s := db.Subscribe(NewPath(), &SubscribeOptions{BufferSize: 2, Policy: DropOldest})
+		defer s.Close()
+		for _, v := range []string{"4", "5", "6"} {
+			put(v)
+		}
+		if n := s.Dropped(); n != 1 {
+			t.Errorf("got %d dropped events, want %d", n, 1)
+		}
+		if got := fmt.Sprint(receiveEvents(s)); got != `[put b/k "4" "5" put b/k "5" "6"]` {
+			t.Errorf("got events %s", got)
+		}
+	})
+
+	t.Run("Disconnect", func(t *testing.T) {
+		s := db.Subscribe(NewPath(), &SubscribeOptions{BufferSize: 1, Policy: Disconnect})
+		defer s.Close()
+		put("7")
+		put("8")
+		if err := s.Err(); err != ErrSlowSubscriber {
+			t.Errorf("got error %v, want %v", err, ErrSlowSubscriber)
+		}
+		if got := fmt.Sprint(receiveEvents(s)); got != `[put b/k "6" "7"]` {
+			t.Errorf("got events %s", got)
+		}
+		if _, ok := <-s.Events(); ok {
+			t.Error("events channel not closed")
+		}
+	})
+
+	t.Run("Block", func(t *testing.T) {
+		s := db.Subscribe(NewPath(), &SubscribeOptions{BufferSize: 1, Policy: Block})
+		defer s.Close()
+		put("9")
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			put("10")
+		}()
+		select {
+		case <-done:
+			t.Fatal("put not blocked")
+		case <-time.After(50 * time.Millisecond):
+		}
+		e := <-s.Events()
+		if string(e.NewValue) != "9" {
+			t.Errorf("got value %q, want %q", e.NewValue, "9")
+		}
+		<-done
+		e = <-s.Events()
+		if string(e.NewValue) != "10" {
+			t.Errorf("got value %q, want %q", e.NewValue, "10")
+		}
+
+		// Closing the subscription unblocks the publisher.
+		put("11")
+		done = make(chan struct{})
+		go func() {
+			defer close(done)
+			put("12")
+		}()
+		time.Sleep(10 * time.Millisecond)
+		s.Close()
+		<-done
+	})
+
+	t.Run("BlockWriteBack", func(t *testing.T) {
+		s := db.Subscribe(NewPath([]byte("b")), &SubscribeOptions{BufferSize: 1, Policy: Block})
+		defer s.Close()
+
+		// The subscriber writes to the DB for every event it receives.
+		handled := make(chan struct{})
+		go func() {
+			defer close(handled)
+			for e := range s.Events() {
+				if _, err := db.Put(ctx, NewKeyPath(e.NewValue, []byte("c")), []byte("seen"), nil); err != nil {
+					t.Errorf("put: %s", err)
+				}
+				if string(e.NewValue) == "last" {
+					return
+				}
+			}
+		}()
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; i < 20; i++ {
+				put(fmt.Sprint(i))
+				s.Err()
+				s.Dropped()
+			}
+			put("last")
+		}()
+		select {
+		case <-done:
+		case <-time.After(10 * time.Second):
+			t.Fatal("writers blocked")
+		}
+		<-handled
+		if v, err := db.Get(ctx, NewKeyPath([]byte("last"), []byte("c"))); err != nil || string(v) != "seen" {
+			t.Errorf("got value %q, error %v", v, err)
+		}
+	})
+}
+
+// receiveEvents returns string representations of all buffered events.
+func receiveEvents(s *Subscription) (events []string) {
+	for {
+		select {
+		case e, ok := <-s.Events():
+			if !ok {
+				return events
+			}
+			events = append(events, fmt.Sprintf("%s %s %q %q", e.Op, e.Path, e.OldValue, e.NewValue))
+		default:
+			return events
+		}
+	}
+}
@@ -0,0 +1,544 @@
+// Copyright (c) 2026, Janoš Guljaš <janos@resenje.org>
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package boltutils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// PathDelimiter separates path elements in the string representation
+// of a Path.
+const PathDelimiter = '/'
+
+// Path is a location in nested buckets. Buckets holds the names of
+// nested buckets, starting from the top level one, and Key is the
+// optional name of a key in the last bucket.
+type Path struct {
+	Buckets [][]byte
+	Key     []byte
+}
+
+// NewPath returns a Path to a bucket named as the last element of
+// buckets arguments nested in buckets named as previous elements.
+func NewPath(buckets ...[]byte) Path {
+	return Path{Buckets: buckets}
+}
+
+// NewKeyPath returns a Path to the key in the bucket named as the last
+// element of buckets arguments nested in buckets named as previous
+// elements.
+func NewKeyPath(key []byte, buckets ...[]byte) Path {
+	return Path{Buckets: buckets, Key: key}
+}
+
+// HasKey returns true if the path references a key and not only a
+// bucket.
+func (p Path) HasKey() bool {
+	return p.Key != nil
+}
+
+// Bucket returns a Path to the bucket that contains the key
+// referenced by this path.
+func (p Path) Bucket() Path {
+	return Path{Buckets: p.Buckets}
+}
+
+// Child returns a Path to the bucket with the provided name nested in
+// the bucket referenced by this path. Key is not preserved.
+func (p Path) Child(name []byte) Path {
+	buckets := make([][]byte, len(p.Buckets), len(p.Buckets)+1)
+	copy(buckets, p.Buckets)
+	return Path{Buckets: append(buckets, name)}
+}
+
+// WithKey returns a Path to the key with the provided name in the
+// bucket referenced by this path.
+func (p Path) WithKey(key []byte) Path {
+	return Path{Buckets: p.Buckets, Key: key}
+}
+
+// Equal returns true if both paths reference the same bucket or key.
+func (p Path) Equal(o Path) bool {
+	if len(p.Buckets) != len(o.Buckets) {
+		return false
+	}
+	for i := range p.Buckets {
+		if !bytes.Equal(p.Buckets[i], o.Buckets[i]) {
+			return false
+		}
+	}
+	if p.HasKey() != o.HasKey() {
+		return false
+	}
+	return bytes.Equal(p.Key, o.Key)
+}
+
+// HasPrefix returns true if the bucket referenced by this path is the
+// same as, or nested in, the bucket referenced by the prefix path.
+// Keys of both paths are ignored.
+func (p Path) HasPrefix(prefix Path) bool {
+	if len(p.Buckets) < len(prefix.Buckets) {
+		return false
+	}
+	for i := range prefix.Buckets {
+		if !bytes.Equal(p.Buckets[i], prefix.Buckets[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// rebase returns the path in which the buckets of the prefix path are
+// replaced by the buckets of the dst path. The path must have the
+// prefix.
+func (p Path) rebase(prefix, dst Path) Path {
+	buckets := make([][]byte, 0, len(dst.Buckets)+len(p.Buckets)-len(prefix.Buckets))
+	buckets = append(buckets, dst.Buckets...)
+	buckets = append(buckets, p.Buckets[len(prefix.Buckets):]...)
+	return Path{Buckets: buckets, Key: p.Key}
+}
+
+// Elements returns bucket names followed by the key, if it is set, in
+// the form accepted by Deep functions.
+func (p Path) Elements() [][]byte {
+	elements := make([][]byte, 0, len(p.Buckets)+1)
+	elements = append(elements, p.Buckets...)
+	if p.HasKey() {
+		elements = append(elements, p.Key)
+	}
+	return elements
+}
+
+// String returns path elements delimited by PathDelimiter. Delimiter
+// and backslash characters are escaped with a backslash and bytes that
+// are not printable UTF-8 characters are represented as \xHH sequences.
+// The result can be parsed with ParsePath or ParseKeyPath.
+func (p Path) String() string {
+	var b strings.Builder
+	for i, e := range p.Elements() {
+		if i > 0 {
+			b.WriteByte(PathDelimiter)
+		}
+		writeEscapedPathElement(&b, e)
+	}
+	return b.String()
+}
+
+func writeEscapedPathElement(b *strings.Builder, e []byte) {
+	for len(e) > 0 {
+		r, size := utf8.DecodeRune(e)
+		switch {
+		case r == PathDelimiter || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == utf8.RuneError && size <= 1, !unicode.IsPrint(r):
+			for _, c := range e[:size] {
+				fmt.Fprintf(b, `\x%02x`, c)
+			}
+		default:
+			b.Write(e[:size])
+		}
+		e = e[size:]
+	}
+}
+
+// ParsePath parses a string formatted as by Path.String and returns a
+// Path with all elements as bucket names.
+func ParsePath(s string) (p Path, err error) {
+	elements, err := parsePathElements(s)
+	if err != nil {
+		return Path{}, err
+	}
+	return Path{Buckets: elements}, nil
+}
+
+// ParseKeyPath parses a string formatted as by Path.String and returns
+// a Path with the last element as the key and all previous elements as
+// bucket names.
+func ParseKeyPath(s string) (p Path, err error) {
+	elements, err := parsePathElements(s)
+	if err != nil {
+		return Path{}, err
+	}
+	length := len(elements)
+	if length < 1 {
+		return Path{}, fmt.Errorf("path %q has no key", s)
+	}
+	return Path{Buckets: elements[:length-1], Key: elements[length-1]}, nil
+}
+
+func parsePathElements(s string) (elements [][]byte, err error) {
+	if s == "" {
+		return nil, nil
+	}
+	var e []byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch c {
+		case PathDelimiter:
+			if len(e) == 0 {
+				return nil, fmt.Errorf("path %q: empty element at %d", s, i)
+			}
+			elements = append(elements, e)
+			e = nil
+		case '\\':
+			if i+1 >= len(s) {
+				return nil, fmt.Errorf("path %q: unterminated escape sequence", s)
+			}
+			i++
+			switch s[i] {
+			case PathDelimiter, '\\':
+				e = append(e, s[i])
+			case 'x':
+				if i+2 >= len(s) {
+					return nil, fmt.Errorf("path %q: short hex escape sequence at %d", s, i-1)
+				}
+				h, ok := unhex(s[i+1], s[i+2])
+				if !ok {
+					return nil, fmt.Errorf("path %q: invalid hex escape sequence at %d", s, i-1)
+				}
+				e = append(e, h)
+				i += 2
+			default:
+				return nil, fmt.Errorf("path %q: invalid escape sequence at %d", s, i-1)
+			}
+		default:
+			e = append(e, c)
+		}
+	}
+	if len(e) == 0 {
+		return nil, fmt.Errorf("path %q: empty element at %d", s, len(s))
+	}
+	return append(elements, e), nil
+}
+
+func unhex(hi, lo byte) (b byte, ok bool) {
+	h, ok := fromHex(hi)
+	if !ok {
+		return 0, false
+	}
+	l, ok := fromHex(lo)
+	if !ok {
+		return 0, false
+	}
+	return h<<4 | l, true
+}
+
+func fromHex(c byte) (b byte, ok bool) {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0', true
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10, true
+	case 'A' <= c && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
+
+// PathBucket retrieves the bucket referenced by the path. Path key is
+// ignored.
+func PathBucket(tx *bolt.Tx, p Path) (bucket *bolt.Bucket) {
+	length := len(p.Buckets)
+	if length < 1 {
+		return nil
+	}
+	bucket = tx.Bucket(p.Buckets[0])
+	if bucket == nil {
+		return nil
+	}
+	for i := 1; i < length; i++ {
+		bucket = bucket.Bucket(p.Buckets[i])
+		if bucket == nil {
+			return nil
+		}
+	}
+	return bucket
+}
+
+// bucketDepth returns the number of leading bucket names that reference
+// existing nested buckets.
+func bucketDepth(tx *bolt.Tx, buckets [][]byte) (depth int) {
+	if len(buckets) < 1 {
+		return 0
+	}
+	bucket := tx.Bucket(buckets[0])
+	for bucket != nil {
+		depth++
+		if depth == len(buckets) {
+			break
+		}
+		bucket = bucket.Bucket(buckets[depth])
+	}
+	return depth
+}
+
+// PathGet retrieves the value of the key referenced by the path.
+// Keys with the expiration time that is not after the current time
+// are treated as missing.
+func PathGet(tx *bolt.Tx, p Path) (data []byte) {
+	if !p.HasKey() {
+		return nil
+	}
+	bucket := PathBucket(tx, p)
+	if bucket == nil {
+		return nil
+	}
+	data = bucket.Get(p.Key)
+	if data != nil && isExpired(tx, p, time.Now()) {
+		return nil
+	}
+	return data
+}
+
+// PathCreateBucketIfNotExists creates nested buckets referenced by the
+// path. Path key is ignored.
+func PathCreateBucketIfNotExists(tx *bolt.Tx, p Path) (bucket *bolt.Bucket, err error) {
+	length := len(p.Buckets)
+	if length < 1 {
+		return nil, fmt.Errorf("insufficient number of buckets %d < 1", length)
+	}
+	bucket, err = tx.CreateBucketIfNotExists(p.Buckets[0])
+	if err != nil {
+		return nil, &PathError{Op: OpCreateBucket, Segments: p.Buckets, Err: err}
+	}
+	for i := 1; i < length; i++ {
+		bucket, err = bucket.CreateBucketIfNotExists(p.Buckets[i])
+		if err != nil {
+			return nil, &PathError{Op: OpCreateBucket, Segments: p.Buckets, Depth: i, Err: err}
+		}
+	}
+	return bucket, nil
+}
+
+// PathPut saves the value under the key referenced by the path, creating
+// all buckets that do not exist. With overwrite argument set to false,
+// this function will return ExistsError if the key already exists.
+// Return value new will be true if the key is put for the first time.
+// Expired keys are treated as missing. The expiration time of the key is
+// removed and its version, if it has one, is changed.
+func PathPut(tx *bolt.Tx, overwrite bool, p Path, value []byte) (new bool, err error) {
+	if !p.HasKey() {
+		return false, fmt.Errorf("path %s has no key", p)
+	}
+	bucket, err := PathCreateBucketIfNotExists(tx, p)
+	if err != nil {
+		return false, err
+	}
+	return putKey(tx, bucket, overwrite, p, value)
+}
+
+// putKey saves the value under the path key in the bucket referenced by
+// the path, with the same behaviour as PathPut.
+func putKey(tx *bolt.Tx, bucket *bolt.Bucket, overwrite bool, p Path, value []byte) (new bool, err error) {
+	new = bucket.Get(p.Key) == nil || isExpired(tx, p, time.Now())
+	if !overwrite && !new {
+		e := p.Elements()
+		return false, &ExistsError{Key: path(e...), Segments: e, Op: OpPut, Depth: len(e)}
+	}
+	if err = bucket.Put(p.Key, value); err != nil {
+		return new, &PathError{Op: OpPut, Segments: p.Elements(), Depth: len(p.Buckets), Err: err}
+	}
+	if err = clearExpiry(tx, p); err != nil {
+		return new, err
+	}
+	if err = updateVersion(tx, p); err != nil {
+		return new, err
+	}
+	return new, nil
+}
+
+// PathDelete deletes the key referenced by the path. With ensure
+// argument set to true, this function will return NotFoundError if
+// the key is not deleted.
+func PathDelete(tx *bolt.Tx, ensure bool, p Path) (err error) {
+	if !p.HasKey() {
+		return fmt.Errorf("path %s has no key", p)
+	}
+	length := len(p.Buckets)
+	if length < 1 {
+		return fmt.Errorf("insufficient number of buckets %d < 1", length)
+	}
+	bucket := tx.Bucket(p.Buckets[0])
+	if bucket == nil {
+		if ensure {
+			return &NotFoundError{Key: string(p.Buckets[0]), Segments: p.Elements(), Op: OpDelete}
+		}
+		return nil
+	}
+	for i := 1; i < length; i++ {
+		bucket = bucket.Bucket(p.Buckets[i])
+		if bucket == nil {
+			if ensure {
+				return &NotFoundError{Key: path(p.Buckets[:i+1]...), Segments: p.Elements(), Op: OpDelete, Depth: i}
+			}
+			return nil
+		}
+	}
+	return deleteKey(tx, bucket, ensure, p)
+}
+
+// deleteKey deletes the path key from the bucket referenced by the path,
+// with the same behaviour as PathDelete.
+func deleteKey(tx *bolt.Tx, bucket *bolt.Bucket, ensure bool, p Path) (err error) {
+	if ensure && bucket.Get(p.Key) == nil {
+		return &NotFoundError{Key: path(p.Buckets...), Segments: p.Elements(), Op: OpDelete, Depth: len(p.Buckets)}
+	}
+	if err = bucket.Delete(p.Key); err != nil {
+		return &PathError{Op: OpDelete, Segments: p.Elements(), Depth: len(p.Buckets), Err: err}
+	}
+	if err = clearExpiry(tx, p); err != nil {
+		return err
+	}
+	return clearVersion(tx, p)
+}
+
+// PathDeleteBucket deletes the bucket referenced by the path. Path key
+// is ignored. With ensure argument set to true, this function will
+// return NotFoundError if the bucket is not deleted. Expiration times and
+// versions of all keys in the deleted buckets are removed.
+func PathDeleteBucket(tx *bolt.Tx, ensure bool, p Path) (err error) {
+	length := len(p.Buckets)
+	if length < 1 {
+		return fmt.Errorf("insufficient number of buckets %d < 1", length)
+	}
+	bucket := tx.Bucket(p.Buckets[0])
+	if bucket == nil {
+		if ensure {
+			return &NotFoundError{Key: string(p.Buckets[0]), Segments: p.Buckets, Op: OpDeleteBucket}
+		}
+		return nil
+	}
+	if length == 1 {
+		if err = tx.DeleteBucket(p.Buckets[0]); err != nil {
+			if err == bolt.ErrBucketNotFound {
+				if ensure {
+					return &NotFoundError{Key: string(p.Buckets[0]), Segments: p.Buckets, Op: OpDeleteBucket, Err: err}
+				}
+				return nil
+			}
+			return &PathError{Op: OpDeleteBucket, Segments: p.Buckets, Err: err}
+		}
+		return deleteBucketMeta(tx, p)
+	}
+	for i := 1; i < length-1; i++ {
+		bucket = bucket.Bucket(p.Buckets[i])
+		if bucket == nil {
+			if ensure {
+				return &NotFoundError{Key: path(p.Buckets[:i+1]...), Segments: p.Buckets, Op: OpDeleteBucket, Depth: i}
+			}
+			return nil
+		}
+	}
+	if err = bucket.DeleteBucket(p.Buckets[length-1]); err != nil {
+		if err == bolt.ErrBucketNotFound {
+			if ensure {
+				return &NotFoundError{Key: path(p.Buckets[:length-1]...), Segments: p.Buckets, Op: OpDeleteBucket, Depth: length - 1, Err: err}
+			}
+			return nil
+		}
+		return &PathError{Op: OpDeleteBucket, Segments: p.Buckets, Depth: length - 1, Err: err}
+	}
+	return deleteBucketMeta(tx, p)
+}
+
+// MarshalBinary encodes the path into a byte slice that can be decoded
+// with UnmarshalBinary. Every bucket name and the key are prefixed by
+// their length, so the encoding is unambiguous for arbitrary names.
+func (p Path) MarshalBinary() (data []byte, err error) {
+	return p.appendBinary(nil), nil
+}
+
+func (p Path) appendBinary(data []byte) []byte {
+	data = appendUvarint(data, uint64(len(p.Buckets)))
+	for _, b := range p.Buckets {
+		data = appendUvarint(data, uint64(len(b)))
+		data = append(data, b...)
+	}
+	if !p.HasKey() {
+		return append(data, 0)
+	}
+	data = append(data, 1)
+	data = appendUvarint(data, uint64(len(p.Key)))
+	return append(data, p.Key...)
+}
+
+// UnmarshalBinary decodes the path encoded by MarshalBinary.
+func (p *Path) UnmarshalBinary(data []byte) (err error) {
+	n, data, err := readUvarint(data)
+	if err != nil {
+		return fmt.Errorf("path buckets count: %w", err)
+	}
+	if n > uint64(len(data)) {
+		return fmt.Errorf("path buckets count %d exceeds data length", n)
+	}
+	buckets := make([][]byte, n)
+	for i := range buckets {
+		buckets[i], data, err = readLengthPrefixed(data)
+		if err != nil {
+			return fmt.Errorf("path bucket %d: %w", i, err)
+		}
+	}
+	if len(data) < 1 {
+		return fmt.Errorf("path key flag: %w", io.ErrUnexpectedEOF)
+	}
+	var key []byte
+	switch data[0] {
+	case 0:
+		data = data[1:]
+	case 1:
+		key, data, err = readLengthPrefixed(data[1:])
+		if err != nil {
+			return fmt.Errorf("path key: %w", err)
+		}
+		if key == nil {
+			key = []byte{}
+		}
+	default:
+		return fmt.Errorf("invalid path key flag %d", data[0])
+	}
+	if len(data) > 0 {
+		return fmt.Errorf("path has %d trailing bytes", len(data))
+	}
+	p.Buckets = buckets
+	p.Key = key
+	return nil
+}
+
+func appendUvarint(data []byte, v uint64) []byte {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(b[:], v)
+	return append(data, b[:n]...)
+}
+
+func readUvarint(data []byte) (v uint64, rest []byte, err error) {
+	v, n := binary.Uvarint(data)
+	if n <= 0 {
+		return 0, nil, errors.New("invalid length")
+	}
+	return v, data[n:], nil
+}
+
+func readLengthPrefixed(data []byte) (e, rest []byte, err error) {
+	l, data, err := readUvarint(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if l > uint64(len(data)) {
+		return nil, nil, io.ErrUnexpectedEOF
+	}
+	return data[:l:l], data[l:], nil
+}
@@ -0,0 +1,201 @@
+// Copyright (c) 2026, Janoš Guljaš <janos@resenje.org>
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package boltutils
+
+import (
+	"bytes"
+	"testing"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestPathString(t *testing.T) {
+	for _, tc := range []struct {
+		path Path
+		s    string
+	}{
+		{
+			path: Path{},
+			s:    "",
+		},
+		{
+			path: NewPath([]byte("bucket1")),
+			s:    "bucket1",
+		},
+		{
+			path: NewPath([]byte("bucket1"), []byte("bucket2")),
+			s:    "bucket1/bucket2",
+		},
+		{
+			path: NewKeyPath([]byte("key"), []byte("bucket1"), []byte("bucket2")),
+			s:    "bucket1/bucket2/key",
+		},
+		{
+			path: NewKeyPath([]byte("a/b"), []byte(`c\d`)),
+			s:    `c\\d/a\/b`,
+		},
+		{
+			path: NewKeyPath([]byte{0, 1, 'a', 0xff}, []byte("ведро")),
+			s:    `ведро/\x00\x01a\xff`,
+		},
+	} {
+		s := tc.path.String()
+		if s != tc.s {
+			t.Errorf("path %q: got string %q, want %q", tc.path.Elements(), s, tc.s)
+		}
+		var p Path
+		var err error
+		if tc.path.HasKey() {
+			p, err = ParseKeyPath(s)
+		} else {
+			p, err = ParsePath(s)
+		}
+		if err != nil {
+			t.Errorf("parse %q: %s", s, err)
+			continue
+		}
+		if !p.Equal(tc.path) {
+			t.Errorf("parse %q: got %q, want %q", s, p.Elements(), tc.path.Elements())
+		}
+	}
+}
+
+func TestParsePathError(t *testing.T) {
+	for _, s := range []string{
+		"/",
+		"a//b",
+		"a/",
+		`a\`,
+		`a\n`,
+		`a\x0`,
+		`a\xzz`,
+	} {
+		if _, err := ParsePath(s); err == nil {
+			t.Errorf("parse %q: expected error", s)
+		}
+	}
+	if _, err := ParseKeyPath(""); err == nil {
+		t.Error("parse empty key path: expected error")
+	}
+}
+
+func TestPath(t *testing.T) {
+	db := newTestDB(t)
+	defer db.Destroy()
+
+	p := NewKeyPath([]byte("key"), []byte("bucket1"), []byte("bucket2"))
+	value := []byte("value")
+
+	if err := db.DB.Update(func(tx *bolt.Tx) error {
+		new, err := PathPut(tx, false, p, value)
+		if err != nil {
+			return err
+		}
+		if !new {
+			t.Error("new is not true")
+		}
+		if _, err := PathPut(tx, false, p, value); !IsExistsError(err) {
+			t.Errorf("invalid error: %v", err)
+		}
+		new, err = PathPut(tx, true, p, []byte("second"))
+		if err != nil {
+			return err
+		}
+		if new {
+			t.Error("new is not false")
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("bolt db update transaction %s", err)
+	}
+
+	if err := db.DB.View(func(tx *bolt.Tx) error {
+		if v := PathGet(tx, p); !bytes.Equal(v, []byte("second")) {
+			t.Errorf("path %s: expected %s, got %s", p, "second", v)
+		}
+		if v := DeepGet(tx, p.Elements()...); !bytes.Equal(v, []byte("second")) {
+			t.Errorf("path %s: expected %s, got %s", p, "second", v)
+		}
+		if v := PathGet(tx, p.Bucket()); v != nil {
+			t.Errorf("path %s: expected nil, got %s", p.Bucket(), v)
+		}
+		if b := PathBucket(tx, p.Bucket()); b == nil {
+			t.Errorf("bucket %s not found", p.Bucket())
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("bolt db view transaction %s", err)
+	}
+
+	if err := db.DB.Update(func(tx *bolt.Tx) error {
+		if err := PathDelete(tx, true, p); err != nil {
+			return err
+		}
+		if err := PathDelete(tx, true, p); !IsNotFoundError(err) {
+			t.Errorf("invalid error: %v", err)
+		}
+		if err := PathDelete(tx, true, p.Bucket()); err == nil {
+			t.Error("expected error for path without key")
+		}
+		if err := PathDeleteBucket(tx, true, p.Bucket()); err != nil {
+			return err
+		}
+		if err := PathDeleteBucket(tx, true, p.Bucket()); !IsNotFoundError(err) {
+			t.Errorf("invalid error: %v", err)
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("bolt db update transaction %s", err)
+	}
+
+	if err := db.DB.View(func(tx *bolt.Tx) error {
+		if b := PathBucket(tx, p.Bucket()); b != nil {
+			t.Errorf("bucket %s found: expected nil", p.Bucket())
+		}
+		if b := PathBucket(tx, NewPath([]byte("bucket1"))); b == nil {
+			t.Errorf("bucket %s not found", "bucket1")
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("bolt db view transaction %s", err)
+	}
+}
+
+func TestPathMarshalBinary(t *testing.T) {
+	for _, p := range []Path{
+		{},
+		NewPath([]byte("bucket1")),
+		NewKeyPath([]byte("key"), []byte("bucket1"), []byte("bucket2")),
+		NewKeyPath([]byte{}, []byte{0, 1}),
+		NewKeyPath([]byte("key")),
+	} {
+		data, err := p.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got Path
+		if err := got.UnmarshalBinary(data); err != nil {
+			t.Errorf("path %q: %s", p.Elements(), err)
+			continue
+		}
+		if !got.Equal(p) {
+			t.Errorf("got %q, want %q", got.Elements(), p.Elements())
+		}
+	}
+	for _, data := range [][]byte{
+		nil,
+		{1},
+		{1, 5, 'a'},
+		{0, 2},
+		{0, 0, 1},
+		{0, 1, 0, 1},
+	} {
+		var p Path
+		if err := p.UnmarshalBinary(data); err == nil {
+			t.Errorf("data %v: expected error", data)
+		}
+	}
+}
@@ -0,0 +1,456 @@
+// Copyright (c) 2026, Janoš Guljaš <janos@resenje.org>
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package boltutils
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Replication protocol.
+//
+// Every message is a frame with a one byte type, four bytes of big
+// endian payload length, the payload and four bytes of big endian
+// CRC-32 (Castagnoli) checksum of all previous frame bytes.
+//
+// The replica starts with the hello frame that holds the protocol
+// version and the sequence of the last applied journal entry. The
+// primary responds with a snapshot if the replica has not applied any
+// entries or the entries it needs are compacted. Snapshot frame holds
+// the journal sequence at the time of the snapshot and it is followed
+// by record frames with buckets and keys, and the snapshot end frame.
+// After that, the primary sends journal entries in entry frames, each
+// batch of entries followed by the sync frame after which the replica
+// commits them.
+const replicationVersion byte = 1
+
+// Replication frame types.
+const (
+	frameHello byte = iota + 1
+	frameSnapshot
+	frameRecord
+	frameSnapshotEnd
+	frameEntry
+	frameSync
+	frameError
+)
+
+// maxFrameLength is the maximal length of a frame payload.
+const maxFrameLength = 1 << 30
+
+// ErrChecksum is returned by replication if a received frame does not
+// match its checksum.
+var ErrChecksum = errors.New("frame checksum mismatch")
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// ReplicationOptions holds optional parameters for DB.ServeReplica.
+type ReplicationOptions struct {
+	// BatchSize is the maximal number of journal entries that the
+	// replica commits in a single transaction. Default is 1000.
+	BatchSize int
+	// PollInterval is the time between two checks for new journal
+	// entries if they are not written with DB methods of the same DB.
+	// Default is one second.
+	PollInterval time.Duration
+}
+
+// ServeReplica sends the content of the database and then its journal
+// entries to the replica on the other side of the connection, until the
+// context is done or the connection fails. The journal must be enabled
+// with DBOptions. If the connection implements io.Closer, it is closed
+// when the context is done. The content of the database is written to a
+// temporary file in the default directory for temporary files before it
+// is sent, so that the read transaction is not held open while the
+// replica receives it.
+func (d *DB) ServeReplica(ctx context.Context, rw io.ReadWriter, opts *ReplicationOptions) (err error) {
+	if !d.journal {
+		return errors.New("journal is not enabled")
+	}
+	if opts == nil {
+		opts = new(ReplicationOptions)
+	}
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+	pollInterval := opts.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = time.Second
+	}
+
+	defer closeOnDone(ctx, rw)()
+	defer func() {
+		if err != nil && ctx.Err() != nil {
+			err = ctx.Err()
+		}
+	}()
+
+	r := bufio.NewReader(rw)
+	w := bufio.NewWriter(rw)
+
+	t, payload, err := readFrame(r)
+	if err != nil {
+		return fmt.Errorf("read hello: %w", err)
+	}
+	if t != frameHello || len(payload) != 9 {
+		return fmt.Errorf("invalid hello frame %d with length %d", t, len(payload))
+	}
+	if v := payload[0]; v != replicationVersion {
+		err = fmt.Errorf("unsupported replication version %d", v)
+		if writeFrame(w, frameError, []byte(err.Error())) == nil {
+			w.Flush()
+		}
+		return err
+	}
+	applied := binary.BigEndian.Uint64(payload[1:])
+
+	// Subscription with the smallest buffer only signals that there may
+	// be new journal entries.
+	s := d.Subscribe(NewPath(), &SubscribeOptions{BufferSize: 1})
+	defer s.Close()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	first := true
+	for {
+		var (
+			entries  []JournalEntry
+			snapshot *os.File
+		)
+		err := d.View(ctx, func(tx *bolt.Tx) (err error) {
+			if !needsSnapshot(tx, applied, first) {
+				entries, err = ReadJournal(tx, applied, batchSize)
+				if !errors.Is(err, ErrJournalCompacted) {
+					return err
+				}
+			}
+			snapshot, applied, err = spoolSnapshot(tx)
+			return err
+		})
+		if snapshot != nil {
+			// The snapshot is sent after the read transaction is closed,
+			// so that a slow replica does not block writers that need
+			// to grow the database.
+			if err == nil {
+				_, err = io.Copy(w, snapshot)
+			}
+			removeSpool(snapshot)
+		}
+		if err != nil {
+			return err
+		}
+		first = false
+		for _, e := range entries {
+			data, err := e.MarshalBinary()
+			if err != nil {
+				return err
+			}
+			if err := writeFrame(w, frameEntry, append(sequenceKey(e.Sequence), data...)); err != nil {
+				return err
+			}
+			applied = e.Sequence
+		}
+		if len(entries) > 0 {
+			if err := writeFrame(w, frameSync, sequenceKey(applied)); err != nil {
+				return err
+			}
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		if len(entries) >= batchSize {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.Events():
+		case <-ticker.C:
+		}
+	}
+}
+
+// needsSnapshot returns true if the replica that applied journal
+// entries up to the applied sequence can not continue with the journal
+// of this database. Replicas that need entries deleted by compaction are
+// detected by ReadJournal.
+func needsSnapshot(tx *bolt.Tx, applied uint64, first bool) bool {
+	if first && applied == 0 {
+		return true
+	}
+	// The replica is not replicating this database if it applied more.
+	return applied > JournalSequence(tx)
+}
+
+// spoolSnapshot writes the snapshot, as written by writeSnapshot, to a
+// temporary file and returns it positioned at its start, together with
+// the journal sequence of the snapshot. The file must be removed with
+// removeSpool.
+func spoolSnapshot(tx *bolt.Tx) (f *os.File, seq uint64, err error) {
+	file, err := os.CreateTemp("", "boltutils-snapshot-*")
+	if err != nil {
+		return nil, 0, err
+	}
+	defer func() {
+		if err != nil {
+			removeSpool(file)
+		}
+	}()
+	w := bufio.NewWriter(file)
+	seq, err = writeSnapshot(tx, w)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := w.Flush(); err != nil {
+		return nil, 0, err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, 0, err
+	}
+	return file, seq, nil
+}
+
+// removeSpool closes and removes the temporary file created by
+// spoolSnapshot.
+func removeSpool(f *os.File) {
+	f.Close()
+	os.Remove(f.Name())
+}
+
+// writeSnapshot writes all buckets and keys, except the meta bucket, and
+// returns the journal sequence of the snapshot.
+func writeSnapshot(tx *bolt.Tx, w io.Writer) (seq uint64, err error) {
+	seq = JournalSequence(tx)
+	if err := writeFrame(w, frameSnapshot, sequenceKey(seq)); err != nil {
+		return 0, err
+	}
+	record := func(e JournalEntry) error {
+		data, err := e.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		return writeFrame(w, frameRecord, data)
+	}
+	if err := DeepForEach(tx, NewPath(), func(p Path, value []byte) error {
+		if !p.HasKey() {
+			return record(JournalEntry{Op: OpCreateBucket, Path: p})
+		}
+		expires, _ := PathExpiry(tx, p)
+		return record(JournalEntry{Op: OpPut, Path: p, Value: value, Expires: expires})
+	}); err != nil {
+		return 0, err
+	}
+	if err := writeFrame(w, frameSnapshotEnd, nil); err != nil {
+		return 0, err
+	}
+	return seq, nil
+}
+
+// Replicate connects to the primary served with DB.ServeReplica on the
+// other side of the connection and applies its content and changes to
+// the database, until the context is done or the connection fails. It
+// can be called again with a new connection to resume from the last
+// applied journal entry. A snapshot from the primary replaces all
+// buckets in the database. If the connection implements io.Closer, it
+// is closed when the context is done.
+func Replicate(ctx context.Context, db *bolt.DB, rw io.ReadWriter) (err error) {
+	defer closeOnDone(ctx, rw)()
+	defer func() {
+		if err != nil && ctx.Err() != nil {
+			err = ctx.Err()
+		}
+	}()
+
+	var applied uint64
+	if err := db.View(func(tx *bolt.Tx) error {
+		applied = AppliedSequence(tx)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	r := bufio.NewReader(rw)
+	w := bufio.NewWriter(rw)
+
+	if err := writeFrame(w, frameHello, append([]byte{replicationVersion}, sequenceKey(applied)...)); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	var entries []JournalEntry
+	for {
+		t, payload, err := readFrame(r)
+		if err != nil {
+			return err
+		}
+		switch t {
+		case frameSnapshot:
+			if len(payload) != 8 {
+				return fmt.Errorf("invalid snapshot frame length %d", len(payload))
+			}
+			seq := binary.BigEndian.Uint64(payload)
+			if err := readSnapshot(db, r, seq); err != nil {
+				return fmt.Errorf("snapshot: %w", err)
+			}
+			applied = seq
+			entries = entries[:0]
+		case frameEntry:
+			if len(payload) < 8 {
+				return fmt.Errorf("invalid entry frame length %d", len(payload))
+			}
+			e := JournalEntry{Sequence: binary.BigEndian.Uint64(payload)}
+			if want := applied + uint64(len(entries)) + 1; e.Sequence != want {
+				return fmt.Errorf("got journal entry %d, want %d", e.Sequence, want)
+			}
+			if err := e.UnmarshalBinary(payload[8:]); err != nil {
+				return fmt.Errorf("journal entry %d: %w", e.Sequence, err)
+			}
+			entries = append(entries, e)
+		case frameSync:
+			if len(payload) != 8 {
+				return fmt.Errorf("invalid sync frame length %d", len(payload))
+			}
+			seq := binary.BigEndian.Uint64(payload)
+			if want := applied + uint64(len(entries)); seq != want {
+				return fmt.Errorf("got sync sequence %d, want %d", seq, want)
+			}
+			if err := db.Update(func(tx *bolt.Tx) error {
+				return ApplyJournal(tx, entries)
+			}); err != nil {
+				return err
+			}
+			applied = seq
+			entries = entries[:0]
+		case frameError:
+			return fmt.Errorf("primary: %s", payload)
+		default:
+			return fmt.Errorf("unexpected frame %d", t)
+		}
+	}
+}
+
+// readSnapshot replaces all buckets, except the meta bucket, with the
+// snapshot records in a single transaction.
+func readSnapshot(db *bolt.DB, r *bufio.Reader, seq uint64) (err error) {
+	return db.Update(func(tx *bolt.Tx) error {
+		var names [][]byte
+		if err := tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+			if !bytes.Equal(name, MetaBucketName) {
+				names = append(names, append([]byte(nil), name...))
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		for _, name := range names {
+			if err := tx.DeleteBucket(name); err != nil {
+				return fmt.Errorf("bucket %s delete: %w", name, err)
+			}
+		}
+		if meta := tx.Bucket(MetaBucketName); meta != nil {
+			for _, name := range [][]byte{ttlIndexBucketName, ttlKeysBucketName, versionsBucketName} {
+				if err := meta.DeleteBucket(name); err != nil && err != bolt.ErrBucketNotFound {
+					return fmt.Errorf("bucket %s delete: %w", path(MetaBucketName, name), err)
+				}
+			}
+		}
+
+		for {
+			t, payload, err := readFrame(r)
+			if err != nil {
+				return err
+			}
+			switch t {
+			case frameRecord:
+				var e JournalEntry
+				if err := e.UnmarshalBinary(payload); err != nil {
+					return err
+				}
+				if err := applyJournalEntry(tx, e); err != nil {
+					return err
+				}
+			case frameSnapshotEnd:
+				return setAppliedSequence(tx, seq)
+			default:
+				return fmt.Errorf("unexpected frame %d", t)
+			}
+		}
+	})
+}
+
+// writeFrame writes a single replication frame.
+func writeFrame(w io.Writer, t byte, payload []byte) (err error) {
+	if len(payload) > maxFrameLength {
+		return fmt.Errorf("frame length %d exceeds %d", len(payload), maxFrameLength)
+	}
+	b := make([]byte, 5, 5+len(payload)+4)
+	b[0] = t
+	binary.BigEndian.PutUint32(b[1:], uint32(len(payload)))
+	b = append(b, payload...)
+	var sum [4]byte
+	binary.BigEndian.PutUint32(sum[:], crc32.Checksum(b, crcTable))
+	_, err = w.Write(append(b, sum[:]...))
+	return err
+}
+
+// readFrame reads a single replication frame and validates its
+// checksum.
+func readFrame(r io.Reader) (t byte, payload []byte, err error) {
+	var header [5]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, nil, err
+	}
+	length := binary.BigEndian.Uint32(header[1:])
+	if length > maxFrameLength {
+		return 0, nil, fmt.Errorf("frame length %d exceeds %d", length, maxFrameLength)
+	}
+	data := make([]byte, int(length)+4)
+	if _, err := io.ReadFull(r, data); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, nil, err
+	}
+	payload, sum := data[:length], data[length:]
+	crc := crc32.Update(crc32.Checksum(header[:], crcTable), crcTable, payload)
+	if crc != binary.BigEndian.Uint32(sum) {
+		return 0, nil, ErrChecksum
+	}
+	return header[0], payload, nil
+}
+
+// closeOnDone closes the connection, if it implements io.Closer, when
+// the context is done, to unblock reads and writes. The returned
+// function stops waiting for the context.
+func closeOnDone(ctx context.Context, rw io.ReadWriter) (stop func()) {
+	c, ok := rw.(io.Closer)
+	if !ok {
+		return func() {}
+	}
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.Close()
+		case <-done:
+		}
+	}()
+	return func() { close(done) }
+}
@@ -0,0 +1,335 @@
+// Copyright (c) 2026, Janoš Guljaš <janos@resenje.org>
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package boltutils
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestReplication(t *testing.T) {
+	primary := newTestDB(t)
+	defer primary.Destroy()
+	replica := newTestDB(t)
+	defer replica.Destroy()
+
+	db := NewDB(primary.DB, &DBOptions{Journal: true})
+	ctx := context.Background()
+
+	put := func(db testDB, elements ...string) {
+		t.Helper()
+		e := make([][]byte, 0, len(elements))
+		for _, s := range elements {
+			e = append(e, []byte(s))
+		}
+		if err := db.DB.Update(func(tx *bolt.Tx) error {
+			_, err := DeepPut(tx, true, e...)
+			return err
+		}); err != nil {
+			t.Fatalf("bolt db update transaction %s", err)
+		}
+	}
+
+	// Keys written before the journal is used are sent in the snapshot.
+	put(primary, "a", "b", "k1", "v1")
+	put(primary, "a", "k2", "v2")
+	if err := primary.DB.Update(func(tx *bolt.Tx) error {
+		_, err := PathCreateBucketIfNotExists(tx, NewPath([]byte("empty")))
+		return err
+	}); err != nil {
+		t.Fatalf("bolt db update transaction %s", err)
+	}
+	if _, err := db.Put(ctx, NewKeyPath([]byte("k3"), []byte("a")), []byte("v3"), &PutOptions{TTL: time.Hour}); err != nil {
+		t.Fatalf("put: %s", err)
+	}
+	// Buckets in the replica are replaced by the snapshot.
+	put(replica, "local", "k", "v")
+
+	connect := func() (stop func()) {
+		t.Helper()
+		ctx, cancel := context.WithCancel(ctx)
+		c1, c2 := net.Pipe()
+		errs := make(chan error, 2)
+		go func() { errs <- db.ServeReplica(ctx, c1, &ReplicationOptions{BatchSize: 2}) }()
+		go func() { errs <- Replicate(ctx, replica.DB, c2) }()
+		return func() {
+			t.Helper()
+			cancel()
+			for i := 0; i < 2; i++ {
+				if err := <-errs; err != context.Canceled {
+					t.Errorf("got error %v, want %v", err, context.Canceled)
+				}
+			}
+		}
+	}
+
+	wait := func() {
+		t.Helper()
+		var want uint64
+		if err := primary.DB.View(func(tx *bolt.Tx) error {
+			want = JournalSequence(tx)
+			return nil
+		}); err != nil {
+			t.Fatalf("bolt db view transaction %s", err)
+		}
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			var got uint64
+			if err := replica.DB.View(func(tx *bolt.Tx) error {
+				got = AppliedSequence(tx)
+				return nil
+			}); err != nil {
+				t.Fatalf("bolt db view transaction %s", err)
+			}
+			if got == want {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("got applied sequence %d, want %d", got, want)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		for _, name := range []string{"a", "empty", "x"} {
+			p := NewPath([]byte(name))
+			if got, want := dump(t, replica, p), dump(t, primary, p); fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Errorf("%s: got %q, want %q", name, got, want)
+			}
+		}
+		if err := replica.DB.View(func(tx *bolt.Tx) error {
+			if PathBucket(tx, NewPath([]byte("empty"))) == nil {
+				t.Error("empty bucket not replicated")
+			}
+			if _, ok := PathExpiry(tx, NewKeyPath([]byte("k3"), []byte("a"))); !ok {
+				t.Error("expiry not replicated")
+			}
+			return nil
+		}); err != nil {
+			t.Fatalf("bolt db view transaction %s", err)
+		}
+	}
+
+	local := func() bool {
+		t.Helper()
+		var ok bool
+		if err := replica.DB.View(func(tx *bolt.Tx) error {
+			ok = PathBucket(tx, NewPath([]byte("local"))) != nil
+			return nil
+		}); err != nil {
+			t.Fatalf("bolt db view transaction %s", err)
+		}
+		return ok
+	}
+
+	stop := connect()
+	wait()
+	if local() {
+		t.Error("replica bucket not replaced by the snapshot")
+	}
+
+	// Changes are streamed while connected.
+	for i := 0; i < 5; i++ {
+		if _, err := db.Put(ctx, NewKeyPath([]byte(fmt.Sprintf("k%d", i)), []byte("x")), []byte("v"), nil); err != nil {
+			t.Fatalf("put: %s", err)
+		}
+	}
+	if err := db.Delete(ctx, true, NewKeyPath([]byte("k2"), []byte("a"))); err != nil {
+		t.Fatalf("delete: %s", err)
+	}
+	wait()
+	stop()
+
+	// Replication is resumed from the last applied entry.
+	put(replica, "local", "k", "v")
+	if err := db.DeleteBucket(ctx, true, NewPath([]byte("a"), []byte("b"))); err != nil {
+		t.Fatalf("delete bucket: %s", err)
+	}
+	if _, err := db.Put(ctx, NewKeyPath([]byte("k4"), []byte("a")), []byte("v4"), nil); err != nil {
+		t.Fatalf("put: %s", err)
+	}
+	stop = connect()
+	wait()
+	stop()
+	if !local() {
+		t.Error("snapshot sent on resume")
+	}
+
+	// Snapshot is sent again if needed entries are compacted.
+	if _, err := db.Put(ctx, NewKeyPath([]byte("k5"), []byte("a")), []byte("v5"), nil); err != nil {
+		t.Fatalf("put: %s", err)
+	}
+	if err := primary.DB.Update(func(tx *bolt.Tx) error {
+		_, err := CompactJournal(tx, JournalSequence(tx))
+		return err
+	}); err != nil {
+		t.Fatalf("bolt db update transaction %s", err)
+	}
+	if _, err := db.Put(ctx, NewKeyPath([]byte("k6"), []byte("a")), []byte("v6"), nil); err != nil {
+		t.Fatalf("put: %s", err)
+	}
+	stop = connect()
+	wait()
+	stop()
+	if local() {
+		t.Error("replica bucket not replaced by the snapshot")
+	}
+}
+
+func TestServeReplicaSnapshot(t *testing.T) {
+	tdb := newTestDB(t)
+	defer tdb.Destroy()
+
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+
+	// The snapshot is larger than the buffer of the connection writer.
+	if err := tdb.DB.Update(func(tx *bolt.Tx) error {
+		_, err := DeepPut(tx, true, []byte("a"), []byte("k"), bytes.Repeat([]byte("v"), 1<<16))
+		return err
+	}); err != nil {
+		t.Fatalf("bolt db update transaction %s", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c1, c2 := net.Pipe()
+	errs := make(chan error, 1)
+	go func() { errs <- NewDB(tdb.DB, &DBOptions{Journal: true}).ServeReplica(ctx, c1, nil) }()
+	if err := writeFrame(c2, frameHello, append([]byte{replicationVersion}, sequenceKey(0)...)); err != nil {
+		t.Fatal(err)
+	}
+	typ, _, err := readFrame(bufio.NewReader(c2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if typ != frameSnapshot {
+		t.Errorf("got frame %d, want %d", typ, frameSnapshot)
+	}
+	// The rest of the snapshot is not read, but the read transaction is
+	// already closed.
+	if n := tdb.DB.Stats().OpenTxN; n != 0 {
+		t.Errorf("got %d open read transactions, want %d", n, 0)
+	}
+
+	cancel()
+	if err := <-errs; err != context.Canceled {
+		t.Errorf("got error %v, want %v", err, context.Canceled)
+	}
+	files, err := os.ReadDir(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 0 {
+		t.Errorf("got %d temporary files, want %d", len(files), 0)
+	}
+}
+
+func TestReplicationErrors(t *testing.T) {
+	tdb := newTestDB(t)
+	defer tdb.Destroy()
+
+	ctx := context.Background()
+
+	t.Run("JournalDisabled", func(t *testing.T) {
+		c1, c2 := net.Pipe()
+		defer c1.Close()
+		defer c2.Close()
+		if err := NewDB(tdb.DB, nil).ServeReplica(ctx, c1, nil); err == nil {
+			t.Error("no error")
+		}
+	})
+
+	t.Run("Version", func(t *testing.T) {
+		c1, c2 := net.Pipe()
+		defer c2.Close()
+		errs := make(chan error, 1)
+		go func() { errs <- NewDB(tdb.DB, &DBOptions{Journal: true}).ServeReplica(ctx, c1, nil) }()
+		if err := writeFrame(c2, frameHello, append([]byte{replicationVersion + 1}, sequenceKey(0)...)); err != nil {
+			t.Fatal(err)
+		}
+		typ, payload, err := readFrame(c2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if typ != frameError || !strings.Contains(string(payload), "unsupported replication version") {
+			t.Errorf("got frame %d %q", typ, payload)
+		}
+		if err := <-errs; err == nil || !strings.Contains(err.Error(), "unsupported replication version") {
+			t.Errorf("invalid error: %v", err)
+		}
+	})
+
+	t.Run("Primary", func(t *testing.T) {
+		c1, c2 := net.Pipe()
+		defer c1.Close()
+		go func() {
+			r := bufio.NewReader(c1)
+			if _, _, err := readFrame(r); err != nil {
+				return
+			}
+			writeFrame(c1, frameError, []byte("failure"))
+		}()
+		if err := Replicate(ctx, tdb.DB, c2); err == nil || err.Error() != "primary: failure" {
+			t.Errorf("invalid error: %v", err)
+		}
+	})
+
+	t.Run("Gap", func(t *testing.T) {
+		c1, c2 := net.Pipe()
+		defer c1.Close()
+		go func() {
+			r := bufio.NewReader(c1)
+			if _, _, err := readFrame(r); err != nil {
+				return
+			}
+			data, _ := JournalEntry{Op: OpDelete, Path: NewKeyPath([]byte("k"), []byte("a"))}.MarshalBinary()
+			writeFrame(c1, frameEntry, append(sequenceKey(2), data...))
+		}()
+		if err := Replicate(ctx, tdb.DB, c2); err == nil || err.Error() != "got journal entry 2, want 1" {
+			t.Errorf("invalid error: %v", err)
+		}
+	})
+}
+
+func TestFrame(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeFrame(&buf, frameRecord, []byte("payload")); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFrame(&buf, frameSnapshotEnd, nil); err != nil {
+		t.Fatal(err)
+	}
+	data := append([]byte(nil), buf.Bytes()...)
+
+	typ, payload, err := readFrame(&buf)
+	if err != nil || typ != frameRecord || string(payload) != "payload" {
+		t.Errorf("got frame %d %q, error %v", typ, payload, err)
+	}
+	typ, payload, err = readFrame(&buf)
+	if err != nil || typ != frameSnapshotEnd || len(payload) != 0 {
+		t.Errorf("got frame %d %q, error %v", typ, payload, err)
+	}
+
+	for i := range data[:16] {
+		corrupted := append([]byte(nil), data...)
+		corrupted[i] ^= 0x01
+		if _, _, err := readFrame(bytes.NewReader(corrupted)); err == nil {
+			t.Errorf("byte %d: no error", i)
+		}
+	}
+	data[7] ^= 0x01
+	if _, _, err := readFrame(bytes.NewReader(data)); err != ErrChecksum {
+		t.Errorf("got error %v, want %v", err, ErrChecksum)
+	}
+}
@@ -0,0 +1,234 @@
+// Copyright (c) 2026, Janoš Guljaš <janos@resenje.org>
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package boltutils
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// ScanOptions holds optional parameters for DeepScanPrefix and
+// DeepScanRange functions.
+type ScanOptions struct {
+	// FromExclusive excludes the lower range bound from results.
+	// By default it is included.
+	FromExclusive bool
+	// ToInclusive includes the upper range bound in results.
+	// By default it is excluded.
+	ToInclusive bool
+	// Reverse returns keys in descending order.
+	Reverse bool
+	// Offset is the number of keys to skip before the first one is
+	// returned.
+	Offset int
+	// Limit is the maximal number of returned keys. If it is zero or
+	// negative, all keys are returned.
+	Limit int
+}
+
+// Scanner iterates over keys in a range of a single bucket. Keys are
+// read from the bucket only when Next is called. Nested buckets and
+// expired keys are not returned, and expired keys are not counted by
+// Offset and Limit options. Key and value slices are valid only for the
+// life of the transaction.
+type Scanner struct {
+	tx     *bolt.Tx
+	bucket Path
+	now    time.Time
+	cursor *bolt.Cursor
+	opts   ScanOptions
+	// expiring is true if any key in the database has an expiration
+	// time.
+	expiring bool
+
+	lower, upper   []byte
+	lowerExclusive bool
+	upperInclusive bool
+
+	started  bool
+	done     bool
+	skipped  int
+	returned int
+
+	key, value []byte
+	err        error
+}
+
+// DeepScanPrefix returns a Scanner over keys that start with the
+// prefix Key in the bucket referenced by the prefix Path. If prefix Key
+// is nil, all keys in the bucket are returned. Range bound options are
+// ignored.
+func DeepScanPrefix(tx *bolt.Tx, prefix Path, opts *ScanOptions) (s *Scanner) {
+	s = newScanner(tx, prefix, opts)
+	if len(prefix.Key) > 0 {
+		s.lower = prefix.Key
+		s.lowerExclusive = false
+		s.upper = prefixSuccessor(prefix.Key)
+		s.upperInclusive = false
+	}
+	return s
+}
+
+// DeepScanRange returns a Scanner over keys between Keys of the from
+// and to paths in the bucket that both paths reference. If from Key is
+// nil, keys are returned from the first one in the bucket, and if to
+// Key is nil, until the last one. By default, the range includes the
+// from Key and excludes the to Key, which can be changed with options.
+func DeepScanRange(tx *bolt.Tx, from, to Path, opts *ScanOptions) (s *Scanner) {
+	s = newScanner(tx, from, opts)
+	if !from.Bucket().Equal(to.Bucket()) {
+		s.err = fmt.Errorf("range buckets %s and %s differ", path(from.Buckets...), path(to.Buckets...))
+		s.done = true
+		return s
+	}
+	s.lower = from.Key
+	s.lowerExclusive = s.opts.FromExclusive
+	s.upper = to.Key
+	s.upperInclusive = s.opts.ToInclusive
+	return s
+}
+
+func newScanner(tx *bolt.Tx, p Path, opts *ScanOptions) (s *Scanner) {
+	s = new(Scanner)
+	if opts != nil {
+		s.opts = *opts
+	}
+	if len(p.Buckets) < 1 {
+		s.err = fmt.Errorf("insufficient number of buckets %d < 1", len(p.Buckets))
+		s.done = true
+		return s
+	}
+	bucket := PathBucket(tx, p)
+	if bucket == nil {
+		s.done = true
+		return s
+	}
+	s.tx = tx
+	s.bucket = p.Bucket()
+	s.now = time.Now()
+	s.cursor = bucket.Cursor()
+	s.expiring = metaBucket(tx, ttlKeysBucketName) != nil
+	return s
+}
+
+// Next advances the Scanner to the next key and returns true if there
+// is one.
+func (s *Scanner) Next() bool {
+	s.key, s.value = nil, nil
+	if s.done {
+		return false
+	}
+	for {
+		var k, v []byte
+		switch {
+		case !s.started:
+			s.started = true
+			if s.opts.Reverse {
+				k, v = s.last()
+			} else {
+				k, v = s.first()
+			}
+		case s.opts.Reverse:
+			k, v = s.cursor.Prev()
+		default:
+			k, v = s.cursor.Next()
+		}
+		if k == nil || !s.inRange(k) {
+			s.done = true
+			return false
+		}
+		if v == nil && s.cursor.Bucket().Bucket(k) != nil {
+			continue
+		}
+		if s.expiring && isExpired(s.tx, s.bucket.WithKey(k), s.now) {
+			continue
+		}
+		if s.skipped < s.opts.Offset {
+			s.skipped++
+			continue
+		}
+		if s.opts.Limit > 0 && s.returned >= s.opts.Limit {
+			s.done = true
+			return false
+		}
+		s.returned++
+		s.key, s.value = k, v
+		return true
+	}
+}
+
+// Key returns the key at the current Scanner position.
+func (s *Scanner) Key() []byte {
+	return s.key
+}
+
+// Value returns the value at the current Scanner position.
+func (s *Scanner) Value() []byte {
+	return s.value
+}
+
+// Err returns the error that prevented the scan, if any.
+func (s *Scanner) Err() error {
+	return s.err
+}
+
+func (s *Scanner) first() (k, v []byte) {
+	if s.lower == nil {
+		return s.cursor.First()
+	}
+	k, v = s.cursor.Seek(s.lower)
+	if k != nil && s.lowerExclusive && bytes.Equal(k, s.lower) {
+		k, v = s.cursor.Next()
+	}
+	return k, v
+}
+
+func (s *Scanner) last() (k, v []byte) {
+	if s.upper == nil {
+		return s.cursor.Last()
+	}
+	k, v = s.cursor.Seek(s.upper)
+	if k == nil {
+		return s.cursor.Last()
+	}
+	if c := bytes.Compare(k, s.upper); c > 0 || (c == 0 && !s.upperInclusive) {
+		k, v = s.cursor.Prev()
+	}
+	return k, v
+}
+
+func (s *Scanner) inRange(k []byte) bool {
+	if s.lower != nil {
+		c := bytes.Compare(k, s.lower)
+		if c < 0 || (c == 0 && s.lowerExclusive) {
+			return false
+		}
+	}
+	if s.upper != nil {
+		c := bytes.Compare(k, s.upper)
+		if c > 0 || (c == 0 && !s.upperInclusive) {
+			return false
+		}
+	}
+	return true
+}
+
+// prefixSuccessor returns the smallest key that is greater than all
+// keys with the provided prefix, or nil if there is no such key.
+func prefixSuccessor(prefix []byte) (s []byte) {
+	s = make([]byte, len(prefix))
+	copy(s, prefix)
+	for i := len(s) - 1; i >= 0; i-- {
+		if s[i] < 0xff {
+			s[i]++
+			return s[:i+1]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+// Copyright (c) 2026, Janoš Guljaš <janos@resenje.org>
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package boltutils
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestDeepScan(t *testing.T) {
+	db := newTestDB(t)
+	defer db.Destroy()
+
+	bucket := NewPath([]byte("bucket1"), []byte("bucket2"))
+
+	if err := db.DB.Update(func(tx *bolt.Tx) error {
+		for _, k := range []string{"a", "aa", "ab", "b", "ba", "c", "d"} {
+			if _, err := PathPut(tx, false, bucket.WithKey([]byte(k)), []byte("v"+k)); err != nil {
+				return err
+			}
+		}
+		// Expired keys are not returned or counted by offset and limit.
+		for _, k := range []string{"0", "aaa", "e"} {
+			if _, err := PathPutWithExpiry(tx, false, bucket.WithKey([]byte(k)), []byte("v"+k), time.Now().Add(-time.Second)); err != nil {
+				return err
+			}
+		}
+		_, err := PathCreateBucketIfNotExists(tx, bucket.Child([]byte("bb")))
+		return err
+	}); err != nil {
+		t.Fatalf("bolt db update transaction %s", err)
+	}
+
+	keys := func(s *Scanner) (got []string) {
+		for s.Next() {
+			if string(s.Value()) != "v"+string(s.Key()) {
+				t.Errorf("key %s: got value %s", s.Key(), s.Value())
+			}
+			got = append(got, string(s.Key()))
+		}
+		if err := s.Err(); err != nil {
+			t.Error(err)
+		}
+		return got
+	}
+
+	for _, tc := range []struct {
+		name     string
+		from, to string
+		prefix   string
+		isPrefix bool
+		opts     *ScanOptions
+		want     []string
+	}{
+		{
+			name: "All",
+			want: []string{"a", "aa", "ab", "b", "ba", "c", "d"},
+		},
+		{
+			name: "Range",
+			from: "aa",
+			to:   "c",
+			want: []string{"aa", "ab", "b", "ba"},
+		},
+		{
+			name: "RangeBounds",
+			from: "aa",
+			to:   "c",
+			opts: &ScanOptions{FromExclusive: true, ToInclusive: true},
+			want: []string{"ab", "b", "ba", "c"},
+		},
+		{
+			name: "RangeMissingBounds",
+			from: "ac",
+			to:   "bz",
+			want: []string{"b", "ba"},
+		},
+		{
+			name: "RangeReverse",
+			from: "aa",
+			to:   "c",
+			opts: &ScanOptions{Reverse: true},
+			want: []string{"ba", "b", "ab", "aa"},
+		},
+		{
+			name: "RangeReverseInclusive",
+			from: "aa",
+			to:   "c",
+			opts: &ScanOptions{Reverse: true, ToInclusive: true, FromExclusive: true},
+			want: []string{"c", "ba", "b", "ab"},
+		},
+		{
+			name: "RangeReverseUnbounded",
+			from: "c",
+			opts: &ScanOptions{Reverse: true},
+			want: []string{"d", "c"},
+		},
+		{
+			name: "OffsetLimit",
+			opts: &ScanOptions{Offset: 2, Limit: 3},
+			want: []string{"ab", "b", "ba"},
+		},
+		{
+			name: "OffsetLimitReverse",
+			opts: &ScanOptions{Offset: 1, Limit: 2, Reverse: true},
+			want: []string{"c", "ba"},
+		},
+		{
+			name:     "Prefix",
+			prefix:   "a",
+			isPrefix: true,
+			want:     []string{"a", "aa", "ab"},
+		},
+		{
+			name:     "PrefixReverse",
+			prefix:   "b",
+			isPrefix: true,
+			opts:     &ScanOptions{Reverse: true},
+			want:     []string{"ba", "b"},
+		},
+		{
+			name:     "PrefixMissing",
+			prefix:   "x",
+			isPrefix: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := db.DB.View(func(tx *bolt.Tx) error {
+				var s *Scanner
+				if tc.isPrefix {
+					s = DeepScanPrefix(tx, bucket.WithKey([]byte(tc.prefix)), tc.opts)
+				} else {
+					from, to := bucket, bucket
+					if tc.from != "" {
+						from = bucket.WithKey([]byte(tc.from))
+					}
+					if tc.to != "" {
+						to = bucket.WithKey([]byte(tc.to))
+					}
+					s = DeepScanRange(tx, from, to, tc.opts)
+				}
+				if got := keys(s); !reflect.DeepEqual(got, tc.want) {
+					t.Errorf("got %q, want %q", got, tc.want)
+				}
+				return nil
+			}); err != nil {
+				t.Fatalf("bolt db view transaction %s", err)
+			}
+		})
+	}
+
+	t.Run("MissingBucket", func(t *testing.T) {
+		if err := db.DB.View(func(tx *bolt.Tx) error {
+			s := DeepScanPrefix(tx, NewPath([]byte("missing")), nil)
+			if s.Next() {
+				t.Error("unexpected key")
+			}
+			return s.Err()
+		}); err != nil {
+			t.Fatalf("bolt db view transaction %s", err)
+		}
+	})
+
+	t.Run("DifferentBuckets", func(t *testing.T) {
+		if err := db.DB.View(func(tx *bolt.Tx) error {
+			s := DeepScanRange(tx, bucket, NewPath([]byte("bucket1")), nil)
+			if s.Next() {
+				t.Error("unexpected key")
+			}
+			if s.Err() == nil {
+				t.Error("expected error")
+			}
+			return nil
+		}); err != nil {
+			t.Fatalf("bolt db view transaction %s", err)
+		}
+	})
+}
+
+func TestPrefixSuccessor(t *testing.T) {
+	for _, tc := range []struct {
+		prefix, want []byte
+	}{
+		{[]byte("a"), []byte("b")},
+		{[]byte{'a', 0xff}, []byte("b")},
+		{[]byte{0xff, 0xff}, nil},
+	} {
+		if got := prefixSuccessor(tc.prefix); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("prefix %v: got %v, want %v", tc.prefix, got, tc.want)
+		}
+	}
+}
@@ -7,6 +7,7 @@ package boltutils
 
 import (
 	"encoding/binary"
+	"fmt"
 	"math"
 	"time"
 )
@@ -35,20 +36,198 @@ func TimeToBytesUTC(t time.Time) (b []byte) {
 // to provide slice. The slice must have length of 9 bytes.
 func PutTimeToBytesUTC(b []byte, t time.Time) {
 	t = t.UTC()
-	binary.BigEndian.PutUint16(b[:2], uint16(t.Year()+yearShift))
-	putUint48(b[2:9], uint64(t.Sub(time.Date(t.Year(), 1, 1, 0, 0, 0, 0, time.UTC))))
+	putYear(b, t)
+	putUint48(b[2:9], uint64(t.Sub(yearStart(t))))
 }
 
 // BytesToTimeUTC converts slice of bytes as described in TimeToBytesUTC
 // to time.Time.
 func BytesToTimeUTC(b []byte) (t time.Time) {
-	y := int(binary.BigEndian.Uint16(b[:2]))
+	ns := getUint48(b[2:TimeBytesLen])
+	return time.Date(getYear(b), 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(ns))
+}
+
+// Lengths of byte slice time representations returned by other time
+// encoding functions.
+const (
+	// TimeSecondsBytesLen is the length of representation returned by
+	// TimeToSecondsBytesUTC.
+	TimeSecondsBytesLen = 6
+	// TimeMillisecondsBytesLen is the length of representation returned
+	// by TimeToMillisecondsBytesUTC.
+	TimeMillisecondsBytesLen = 7
+	// TimeZoneBytesLen is the length of representation returned by
+	// TimeToZoneBytes.
+	TimeZoneBytesLen = TimeBytesLen + 4
+	// TimeUnixBytesLen is the length of representation returned by
+	// TimeToUnixBytes.
+	TimeUnixBytesLen = 8
+)
+
+// TimeToSecondsBytesUTC returns slice of bytes that represent provided
+// time in UTC with the precision of one second. Slice length is always
+// 6, where first 2 bytes represent the year as in TimeToBytesUTC and the
+// rest 4 bytes seconds since the beginning of the year. Time is
+// truncated to the whole second.
+func TimeToSecondsBytesUTC(t time.Time) (b []byte) {
+	b = make([]byte, TimeSecondsBytesLen)
+	t = t.UTC()
+	putYear(b, t)
+	binary.BigEndian.PutUint32(b[2:6], uint32(t.Sub(yearStart(t))/time.Second))
+	return b
+}
+
+// SecondsBytesToTimeUTC converts slice of bytes as described in
+// TimeToSecondsBytesUTC to time.Time.
+func SecondsBytesToTimeUTC(b []byte) (t time.Time, err error) {
+	if err := checkTimeBytesLen(b, TimeSecondsBytesLen); err != nil {
+		return time.Time{}, err
+	}
+	s := binary.BigEndian.Uint32(b[2:6])
+	return time.Date(getYear(b), 1, 1, 0, 0, int(s), 0, time.UTC), nil
+}
+
+// TimeToMillisecondsBytesUTC returns slice of bytes that represent
+// provided time in UTC with the precision of one millisecond. Slice
+// length is always 7, where first 2 bytes represent the year as in
+// TimeToBytesUTC and the rest 5 bytes milliseconds since the beginning
+// of the year. Time is truncated to the whole millisecond.
+func TimeToMillisecondsBytesUTC(t time.Time) (b []byte) {
+	b = make([]byte, TimeMillisecondsBytesLen)
+	t = t.UTC()
+	putYear(b, t)
+	putUint40(b[2:7], uint64(t.Sub(yearStart(t))/time.Millisecond))
+	return b
+}
+
+// MillisecondsBytesToTimeUTC converts slice of bytes as described in
+// TimeToMillisecondsBytesUTC to time.Time.
+func MillisecondsBytesToTimeUTC(b []byte) (t time.Time, err error) {
+	if err := checkTimeBytesLen(b, TimeMillisecondsBytesLen); err != nil {
+		return time.Time{}, err
+	}
+	ms := getUint40(b[2:7])
+	return time.Date(getYear(b), 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(ms) * time.Millisecond), nil
+}
+
+// TimeToZoneBytes returns slice of bytes that represent provided time
+// as TimeToBytesUTC does, followed by 4 bytes of the time zone offset in
+// seconds. Representations are sorted by the time instant, and times at
+// the same instant by their zone offsets. Zone name is not preserved.
+func TimeToZoneBytes(t time.Time) (b []byte) {
+	b = make([]byte, TimeZoneBytesLen)
+	PutTimeToBytesUTC(b, t)
+	_, offset := t.Zone()
+	binary.BigEndian.PutUint32(b[TimeBytesLen:], uint32(int32(offset))^(1<<31))
+	return b
+}
+
+// ZoneBytesToTime converts slice of bytes as described in
+// TimeToZoneBytes to time.Time in a fixed time zone with the encoded
+// offset. If the offset is zero, time is in UTC.
+func ZoneBytesToTime(b []byte) (t time.Time, err error) {
+	if err := checkTimeBytesLen(b, TimeZoneBytesLen); err != nil {
+		return time.Time{}, err
+	}
+	t = BytesToTimeUTC(b[:TimeBytesLen])
+	offset := int(int32(binary.BigEndian.Uint32(b[TimeBytesLen:]) ^ (1 << 31)))
+	if offset == 0 {
+		return t, nil
+	}
+	return t.In(time.FixedZone("", offset)), nil
+}
+
+// TimeToUnixBytes returns slice of bytes with the big endian
+// representation of the number of nanoseconds since the Unix epoch, as
+// returned by time.Time.UnixNano, which is a common format in other
+// tools. Representations are sortable only for times after the Unix
+// epoch, and only times between years 1678 and 2262 can be represented.
+func TimeToUnixBytes(t time.Time) (b []byte) {
+	b = make([]byte, TimeUnixBytesLen)
+	binary.BigEndian.PutUint64(b, uint64(t.UnixNano()))
+	return b
+}
+
+// UnixBytesToTime converts slice of bytes as described in
+// TimeToUnixBytes to time.Time in UTC.
+func UnixBytesToTime(b []byte) (t time.Time, err error) {
+	if err := checkTimeBytesLen(b, TimeUnixBytesLen); err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, int64(binary.BigEndian.Uint64(b))).UTC(), nil
+}
+
+func checkTimeBytesLen(b []byte, want int) error {
+	if len(b) != want {
+		return fmt.Errorf("invalid time bytes length %d, expected %d", len(b), want)
+	}
+	return nil
+}
+
+func putYear(b []byte, t time.Time) {
+	binary.BigEndian.PutUint16(b[:2], uint16(t.Year()+yearShift))
+}
+
+func getYear(b []byte) (y int) {
+	y = int(binary.BigEndian.Uint16(b[:2]))
 	if y > 0 {
 		y--
 	}
-	y -= math.MaxInt16
+	return y - math.MaxInt16
+}
+
+func yearStart(t time.Time) time.Time {
+	return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// PutTimeBytes puts bytes representation of provided time, as described
+// in TimeToBytesUTC, to the provided slice. It returns an error if the
+// slice length is not TimeBytesLen or if the time year is out of the
+// representable range.
+func PutTimeBytes(b []byte, t time.Time) (err error) {
+	if err := checkTimeBytesLen(b, TimeBytesLen); err != nil {
+		return err
+	}
+	if y := t.UTC().Year(); y < -math.MaxInt16 || y > math.MaxInt16 {
+		return fmt.Errorf("time year %d out of range", y)
+	}
+	PutTimeToBytesUTC(b, t)
+	return nil
+}
+
+// ParseTimeBytes converts slice of bytes as described in TimeToBytesUTC
+// to time.Time. Unlike BytesToTimeUTC, it returns an error instead of
+// panicking if the slice length is not TimeBytesLen, and it validates
+// that the encoded values are in range.
+func ParseTimeBytes(b []byte) (t time.Time, err error) {
+	if err := checkTimeBytesLen(b, TimeBytesLen); err != nil {
+		return time.Time{}, err
+	}
+	if binary.BigEndian.Uint16(b[:2]) == 0 {
+		return time.Time{}, fmt.Errorf("time year out of range")
+	}
+	y := getYear(b)
 	ns := getUint48(b[2:TimeBytesLen])
-	return time.Date(y, 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(ns))
+	start := time.Date(y, 1, 1, 0, 0, 0, 0, time.UTC)
+	if ns >= uint64(start.AddDate(1, 0, 0).Sub(start)) {
+		return time.Time{}, fmt.Errorf("time nanoseconds %d exceed year %d", ns, y)
+	}
+	return start.Add(time.Duration(ns)), nil
+}
+
+func putUint40(b []byte, v uint64) {
+	_ = b[4]
+	b[0] = byte(v >> 32)
+	b[1] = byte(v >> 24)
+	b[2] = byte(v >> 16)
+	b[3] = byte(v >> 8)
+	b[4] = byte(v)
+}
+
+func getUint40(b []byte) uint64 {
+	_ = b[4]
+	return uint64(b[4]) | uint64(b[3])<<8 | uint64(b[2])<<16 |
+		uint64(b[1])<<24 | uint64(b[0])<<32
 }
 
 func putUint48(b []byte, v uint64) {
@@ -69,3 +69,243 @@ func TestTime(t *testing.T) {
 		}
 	}
 }
+
+func TestTimeEncodings(t *testing.T) {
+	times := []time.Time{
+		time.Date(-math.MaxInt16, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(0, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Unix(0, 0).UTC(),
+		time.Date(2000, 12, 31, 23, 59, 59, 999000000, time.UTC),
+		time.Date(2018, 6, 28, 8, 15, 0, 659000000, time.UTC),
+		time.Date(2018, 6, 28, 8, 15, 1, 0, time.UTC),
+		time.Date(math.MaxInt16, 12, 31, 23, 59, 59, 0, time.UTC),
+	}
+
+	for _, tc := range []struct {
+		name   string
+		length int
+		encode func(time.Time) []byte
+		decode func([]byte) (time.Time, error)
+		trunc  time.Duration
+	}{
+		{
+			name:   "Seconds",
+			length: TimeSecondsBytesLen,
+			encode: TimeToSecondsBytesUTC,
+			decode: SecondsBytesToTimeUTC,
+			trunc:  time.Second,
+		},
+		{
+			name:   "Milliseconds",
+			length: TimeMillisecondsBytesLen,
+			encode: TimeToMillisecondsBytesUTC,
+			decode: MillisecondsBytesToTimeUTC,
+			trunc:  time.Millisecond,
+		},
+		{
+			name:   "Zone",
+			length: TimeZoneBytesLen,
+			encode: TimeToZoneBytes,
+			decode: ZoneBytesToTime,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var prev []byte
+			for _, tm := range times {
+				b := tc.encode(tm)
+				if len(b) != tc.length {
+					t.Errorf("time %s: got length %d, want %d", tm, len(b), tc.length)
+				}
+				if prev != nil && bytes.Compare(prev, b) >= 0 {
+					t.Errorf("time %s: %v is not greater than %v", tm, b, prev)
+				}
+				prev = b
+				got, err := tc.decode(b)
+				if err != nil {
+					t.Fatal(err)
+				}
+				want := tm
+				if tc.trunc > 0 {
+					want = yearStart(tm).Add(tm.Sub(yearStart(tm)).Truncate(tc.trunc))
+				}
+				if !got.Equal(want) {
+					t.Errorf("time %s: got %s, want %s", tm, got, want)
+				}
+			}
+			if _, err := tc.decode(make([]byte, tc.length-1)); err == nil {
+				t.Error("expected error for short input")
+			}
+		})
+	}
+
+	t.Run("Truncate", func(t *testing.T) {
+		tm := time.Date(2018, 6, 28, 8, 15, 0, 659847297, time.UTC)
+		got, err := SecondsBytesToTimeUTC(TimeToSecondsBytesUTC(tm))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := time.Date(2018, 6, 28, 8, 15, 0, 0, time.UTC); !got.Equal(want) {
+			t.Errorf("got %s, want %s", got, want)
+		}
+		got, err = MillisecondsBytesToTimeUTC(TimeToMillisecondsBytesUTC(tm))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := time.Date(2018, 6, 28, 8, 15, 0, 659000000, time.UTC); !got.Equal(want) {
+			t.Errorf("got %s, want %s", got, want)
+		}
+	})
+
+	t.Run("ZoneOffset", func(t *testing.T) {
+		utc := time.Date(2018, 6, 28, 8, 15, 0, 0, time.UTC)
+		east := utc.In(time.FixedZone("CEST", 2*60*60))
+		west := utc.In(time.FixedZone("EDT", -4*60*60))
+		bWest, bUTC, bEast := TimeToZoneBytes(west), TimeToZoneBytes(utc), TimeToZoneBytes(east)
+		if bytes.Compare(bWest, bUTC) >= 0 || bytes.Compare(bUTC, bEast) >= 0 {
+			t.Errorf("same instant is not sorted by offset: %v %v %v", bWest, bUTC, bEast)
+		}
+		got, err := ZoneBytesToTime(bEast)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !got.Equal(east) {
+			t.Errorf("got %s, want %s", got, east)
+		}
+		if _, offset := got.Zone(); offset != 2*60*60 {
+			t.Errorf("got offset %d, want %d", offset, 2*60*60)
+		}
+		if got.Hour() != 10 {
+			t.Errorf("got hour %d, want %d", got.Hour(), 10)
+		}
+	})
+
+	t.Run("Unix", func(t *testing.T) {
+		tm := time.Date(2018, 6, 28, 8, 15, 0, 659847297, time.UTC)
+		b := TimeToUnixBytes(tm)
+		if want := []byte{0x15, 0x3c, 0x44, 0xdf, 0xa0, 0x79, 0xa0, 0x81}; !bytes.Equal(b, want) {
+			t.Errorf("got %#v, want %#v", b, want)
+		}
+		got, err := UnixBytesToTime(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !got.Equal(tm) {
+			t.Errorf("got %s, want %s", got, tm)
+		}
+		if _, err := UnixBytesToTime(b[:7]); err == nil {
+			t.Error("expected error for short input")
+		}
+	})
+}
+
+func TestParseTimeBytes(t *testing.T) {
+	for _, b := range [][]byte{
+		nil,
+		{128, 1, 0, 0, 0, 0, 0, 0},
+		{128, 1, 0, 0, 0, 0, 0, 0, 0, 0},
+		{0, 0, 0, 0, 0, 0, 0, 0, 0},
+		{135, 226, 255, 255, 255, 255, 255, 255, 255},
+	} {
+		if tm, err := ParseTimeBytes(b); err == nil {
+			t.Errorf("bytes %v: expected error, got %s", b, tm)
+		}
+	}
+
+	tm := time.Date(2018, 6, 28, 8, 15, 0, 659847297, time.UTC)
+	b := make([]byte, TimeBytesLen)
+	if err := PutTimeBytes(b, tm); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ParseTimeBytes(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(tm) {
+		t.Errorf("got %s, want %s", got, tm)
+	}
+
+	if err := PutTimeBytes(make([]byte, TimeBytesLen-1), tm); err == nil {
+		t.Error("expected error for short slice")
+	}
+	if err := PutTimeBytes(b, time.Date(math.MaxInt16+1, 1, 1, 0, 0, 0, 0, time.UTC)); err == nil {
+		t.Error("expected error for year out of range")
+	}
+}
+
+// fuzzTime returns a time in the range that can be represented by
+// TimeToBytesUTC, constructed from arbitrary seconds and nanoseconds.
+func fuzzTime(sec, nsec int64) time.Time {
+	min := time.Date(-math.MaxInt16, 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+	max := time.Date(math.MaxInt16, 12, 31, 23, 59, 59, 0, time.UTC).Unix()
+	sec %= max - min + 1
+	if sec < 0 {
+		sec += max - min + 1
+	}
+	nsec %= int64(time.Second)
+	if nsec < 0 {
+		nsec += int64(time.Second)
+	}
+	return time.Unix(min+sec, nsec).UTC()
+}
+
+func compareTimes(a, b time.Time) int {
+	switch {
+	case a.Before(b):
+		return -1
+	case a.After(b):
+		return 1
+	}
+	return 0
+}
+
+func FuzzTimeBytes(f *testing.F) {
+	f.Add(int64(0), int64(0), int64(0), int64(1))
+	f.Add(int64(1530173700), int64(659847297), int64(1530173700), int64(659847298))
+	f.Add(int64(-1), int64(-1), int64(math.MaxInt64), int64(math.MinInt64))
+	f.Fuzz(func(t *testing.T, sec1, nsec1, sec2, nsec2 int64) {
+		t1, t2 := fuzzTime(sec1, nsec1), fuzzTime(sec2, nsec2)
+		b1, b2 := TimeToBytesUTC(t1), TimeToBytesUTC(t2)
+
+		got, err := ParseTimeBytes(b1)
+		if err != nil {
+			t.Fatalf("time %s: %s", t1, err)
+		}
+		if !got.Equal(t1) {
+			t.Errorf("got %s, want %s", got, t1)
+		}
+		if got := BytesToTimeUTC(b1); !got.Equal(t1) {
+			t.Errorf("got %s, want %s", got, t1)
+		}
+
+		if c := bytes.Compare(b1, b2); c != compareTimes(t1, t2) {
+			t.Errorf("times %s and %s: got bytes comparison %d", t1, t2, c)
+		}
+		if c := bytes.Compare(TimeToSecondsBytesUTC(t1), TimeToSecondsBytesUTC(t2)); c != compareTimes(t1.Truncate(time.Second), t2.Truncate(time.Second)) {
+			t.Errorf("times %s and %s: got seconds bytes comparison %d", t1, t2, c)
+		}
+	})
+}
+
+func FuzzParseTimeBytes(f *testing.F) {
+	f.Add([]byte{135, 226, 54, 190, 80, 66, 53, 160, 129})
+	f.Add([]byte{0, 0, 0, 0, 0, 0, 0, 0, 0})
+	f.Add([]byte{255, 255, 255, 255, 255, 255, 255, 255, 255})
+	f.Add([]byte{1})
+	f.Fuzz(func(t *testing.T, b []byte) {
+		tm, err := ParseTimeBytes(b)
+		if err != nil {
+			return
+		}
+		if got := TimeToBytesUTC(tm); !bytes.Equal(got, b) {
+			t.Errorf("time %s: got bytes %v, want %v", tm, got, b)
+		}
+		for _, decode := range []func([]byte) (time.Time, error){
+			SecondsBytesToTimeUTC,
+			MillisecondsBytesToTimeUTC,
+			ZoneBytesToTime,
+			UnixBytesToTime,
+		} {
+			_, _ = decode(b)
+		}
+	})
+}
@@ -0,0 +1,133 @@
+// Copyright (c) 2026, Janoš Guljaš <janos@resenje.org>
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package boltutils
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// TimeSeriesKeyLen is the length of keys under which TimeSeries stores
+// values. First TimeBytesLen bytes are the time as encoded by
+// TimeToBytesUTC, followed by 8 bytes of the bucket sequence number
+// that distinguishes values stored at the same time.
+const TimeSeriesKeyLen = TimeBytesLen + 8
+
+// TimeSeries stores values under time keys in the bucket referenced by
+// Path. Path key is ignored. Keys are sorted by time, and values put at
+// the same time are sorted in the order they are put.
+type TimeSeries struct {
+	Path Path
+}
+
+// TimeSeriesEntry is a value stored in TimeSeries with its time and key.
+type TimeSeriesEntry struct {
+	Time  time.Time
+	Key   []byte
+	Value []byte
+}
+
+// NewTimeSeries returns a TimeSeries that stores values in the bucket
+// named as the last element of buckets arguments nested in buckets
+// named as previous elements.
+func NewTimeSeries(buckets ...[]byte) (s TimeSeries) {
+	return TimeSeries{Path: NewPath(buckets...)}
+}
+
+// Put saves the value under the key constructed from the provided time,
+// creating all buckets that do not exist. The returned key can be used
+// to retrieve or delete the value with Path functions.
+func (s TimeSeries) Put(tx *bolt.Tx, t time.Time, value []byte) (key []byte, err error) {
+	bucket, err := PathCreateBucketIfNotExists(tx, s.Path)
+	if err != nil {
+		return nil, err
+	}
+	seq, err := bucket.NextSequence()
+	if err != nil {
+		return nil, fmt.Errorf("bucket %s next sequence: %w", path(s.Path.Buckets...), err)
+	}
+	key = make([]byte, TimeSeriesKeyLen)
+	PutTimeToBytesUTC(key, t)
+	binary.BigEndian.PutUint64(key[TimeBytesLen:], seq)
+	if err = bucket.Put(key, value); err != nil {
+		return nil, fmt.Errorf("bucket %s put %x: %w", path(s.Path.Buckets...), key, err)
+	}
+	return key, nil
+}
+
+// Between returns a Scanner over values stored at times from the
+// provided from time, inclusive, to the provided to time, exclusive.
+// Keys returned by the Scanner can be parsed with ParseTimeSeriesKey.
+// Only Reverse, Offset and Limit options are used.
+func (s TimeSeries) Between(tx *bolt.Tx, from, to time.Time, opts *ScanOptions) (scanner *Scanner) {
+	o := ScanOptions{}
+	if opts != nil {
+		o.Reverse = opts.Reverse
+		o.Offset = opts.Offset
+		o.Limit = opts.Limit
+	}
+	return DeepScanRange(tx, s.Path.WithKey(TimeToBytesUTC(from)), s.Path.WithKey(TimeToBytesUTC(to)), &o)
+}
+
+// Latest returns up to n most recent entries, starting from the latest
+// one.
+func (s TimeSeries) Latest(tx *bolt.Tx, n int) (entries []TimeSeriesEntry, err error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	scanner := DeepScanPrefix(tx, s.Path.Bucket(), &ScanOptions{
+		Reverse: true,
+		Limit:   n,
+	})
+	for scanner.Next() {
+		t, _, err := ParseTimeSeriesKey(scanner.Key())
+		if err != nil {
+			return nil, fmt.Errorf("bucket %s: %w", path(s.Path.Buckets...), err)
+		}
+		entries = append(entries, TimeSeriesEntry{
+			Time:  t,
+			Key:   scanner.Key(),
+			Value: scanner.Value(),
+		})
+	}
+	return entries, scanner.Err()
+}
+
+// DeleteBefore deletes all values stored at times before the cutoff
+// time and returns the number of deleted values.
+func (s TimeSeries) DeleteBefore(tx *bolt.Tx, cutoff time.Time) (n int, err error) {
+	bucket := PathBucket(tx, s.Path)
+	if bucket == nil {
+		return 0, nil
+	}
+	scanner := DeepScanRange(tx, s.Path.Bucket(), s.Path.WithKey(TimeToBytesUTC(cutoff)), nil)
+	var keys [][]byte
+	for scanner.Next() {
+		keys = append(keys, append([]byte(nil), scanner.Key()...))
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	for _, k := range keys {
+		if err := bucket.Delete(k); err != nil {
+			return n, fmt.Errorf("bucket %s delete %x: %w", path(s.Path.Buckets...), k, err)
+		}
+		n++
+	}
+	return n, nil
+}
+
+// ParseTimeSeriesKey returns the time and the sequence number encoded in
+// the key under which TimeSeries stores values.
+func ParseTimeSeriesKey(key []byte) (t time.Time, seq uint64, err error) {
+	if len(key) != TimeSeriesKeyLen {
+		return time.Time{}, 0, fmt.Errorf("invalid time series key length %d", len(key))
+	}
+	return BytesToTimeUTC(key[:TimeBytesLen]), binary.BigEndian.Uint64(key[TimeBytesLen:]), nil
+}
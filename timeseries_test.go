@@ -0,0 +1,109 @@
+// Copyright (c) 2026, Janoš Guljaš <janos@resenje.org>
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package boltutils
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestTimeSeries(t *testing.T) {
+	db := newTestDB(t)
+	defer db.Destroy()
+
+	s := NewTimeSeries([]byte("bucket1"), []byte("events"))
+	start := time.Date(2018, 6, 28, 8, 15, 0, 0, time.UTC)
+
+	if err := db.DB.Update(func(tx *bolt.Tx) error {
+		for i, v := range []string{"v0", "v1", "v2", "v3", "v4"} {
+			if _, err := s.Put(tx, start.Add(time.Duration(i)*time.Minute), []byte(v)); err != nil {
+				return err
+			}
+		}
+		// Value at the same time as v2 must not overwrite it.
+		key, err := s.Put(tx, start.Add(2*time.Minute), []byte("v2b"))
+		if err != nil {
+			return err
+		}
+		tm, seq, err := ParseTimeSeriesKey(key)
+		if err != nil {
+			return err
+		}
+		if !tm.Equal(start.Add(2 * time.Minute)) {
+			t.Errorf("got key time %s, want %s", tm, start.Add(2*time.Minute))
+		}
+		if seq != 6 {
+			t.Errorf("got key sequence %d, want %d", seq, 6)
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("bolt db update transaction %s", err)
+	}
+
+	values := func(scanner *Scanner) (got []string) {
+		for scanner.Next() {
+			got = append(got, string(scanner.Value()))
+		}
+		if err := scanner.Err(); err != nil {
+			t.Error(err)
+		}
+		return got
+	}
+
+	if err := db.DB.View(func(tx *bolt.Tx) error {
+		got := values(s.Between(tx, start.Add(time.Minute), start.Add(3*time.Minute), nil))
+		want := []string{"v1", "v2", "v2b"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("between: got %q, want %q", got, want)
+		}
+
+		got = values(s.Between(tx, start, start.Add(time.Hour), &ScanOptions{Reverse: true, Limit: 2}))
+		want = []string{"v4", "v3"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("between reverse: got %q, want %q", got, want)
+		}
+
+		entries, err := s.Latest(tx, 3)
+		if err != nil {
+			return err
+		}
+		got = nil
+		for _, e := range entries {
+			got = append(got, string(e.Value))
+		}
+		want = []string{"v4", "v3", "v2b"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("latest: got %q, want %q", got, want)
+		}
+		if !entries[0].Time.Equal(start.Add(4 * time.Minute)) {
+			t.Errorf("latest: got time %s, want %s", entries[0].Time, start.Add(4*time.Minute))
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("bolt db view transaction %s", err)
+	}
+
+	if err := db.DB.Update(func(tx *bolt.Tx) error {
+		n, err := s.DeleteBefore(tx, start.Add(2*time.Minute))
+		if err != nil {
+			return err
+		}
+		if n != 2 {
+			t.Errorf("deleted %d, want %d", n, 2)
+		}
+		got := values(s.Between(tx, time.Time{}, start.Add(time.Hour), nil))
+		want := []string{"v2", "v2b", "v3", "v4"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("after delete: got %q, want %q", got, want)
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("bolt db update transaction %s", err)
+	}
+}
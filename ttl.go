@@ -0,0 +1,262 @@
+// Copyright (c) 2026, Janoš Guljaš <janos@resenje.org>
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package boltutils
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	// ttlIndexBucketName is the name of the meta bucket with keys
+	// composed of the expiration time, as encoded by TimeToBytesUTC,
+	// followed by the path of the expiring key, as encoded by
+	// appendMetaKey.
+	ttlIndexBucketName = []byte("ttl-index")
+	// ttlKeysBucketName is the name of the meta bucket with paths of
+	// expiring keys, as encoded by appendMetaKey, as keys and their
+	// expiration times as values.
+	ttlKeysBucketName = []byte("ttl-keys")
+)
+
+// PathPutWithExpiry saves the value under the key referenced by the path
+// in the same way as PathPut does, and records the expiration time of
+// the key. Once the expiration time is reached, PathGet and DeepGet
+// treat the key as missing until it is deleted by Sweep.
+func PathPutWithExpiry(tx *bolt.Tx, overwrite bool, p Path, value []byte, expires time.Time) (new bool, err error) {
+	new, err = PathPut(tx, overwrite, p, value)
+	if err != nil {
+		return new, err
+	}
+	if err := setExpiry(tx, p, expires); err != nil {
+		return new, err
+	}
+	return new, nil
+}
+
+// DeepPutWithTTL saves the value in the same way as DeepPut does, and
+// records that the key expires after the ttl duration from now.
+func DeepPutWithTTL(tx *bolt.Tx, overwrite bool, ttl time.Duration, elements ...[]byte) (new bool, err error) {
+	length := len(elements)
+	if length < 3 {
+		return false, fmt.Errorf("insufficient number of elements %d < 3", length)
+	}
+	return PathPutWithExpiry(tx, overwrite, NewKeyPath(elements[length-2], elements[:length-2]...), elements[length-1], time.Now().Add(ttl))
+}
+
+// PathExpiry returns the expiration time of the key referenced by the
+// path. Return value ok is false if the key has no expiration time.
+func PathExpiry(tx *bolt.Tx, p Path) (expires time.Time, ok bool) {
+	keys := metaBucket(tx, ttlKeysBucketName)
+	if keys == nil {
+		return time.Time{}, false
+	}
+	v := keys.Get(appendMetaKey(nil, p))
+	if len(v) != TimeBytesLen {
+		return time.Time{}, false
+	}
+	return BytesToTimeUTC(v), true
+}
+
+// isExpired returns true if the key referenced by the path has the
+// expiration time that is not after now.
+func isExpired(tx *bolt.Tx, p Path, now time.Time) bool {
+	expires, ok := PathExpiry(tx, p)
+	return ok && !expires.After(now)
+}
+
+func setExpiry(tx *bolt.Tx, p Path, expires time.Time) (err error) {
+	keys, err := createMetaBucket(tx, ttlKeysBucketName)
+	if err != nil {
+		return err
+	}
+	index, err := createMetaBucket(tx, ttlIndexBucketName)
+	if err != nil {
+		return err
+	}
+	k := appendMetaKey(nil, p)
+	t := TimeToBytesUTC(expires)
+	if err := keys.Put(k, t); err != nil {
+		return fmt.Errorf("bucket %s put %s: %w", path(MetaBucketName, ttlKeysBucketName), p, err)
+	}
+	if err := index.Put(append(t, k...), []byte{}); err != nil {
+		return fmt.Errorf("bucket %s put %s: %w", path(MetaBucketName, ttlIndexBucketName), p, err)
+	}
+	return nil
+}
+
+// clearExpiry removes the expiration time of the key referenced by the
+// path, if it is set.
+func clearExpiry(tx *bolt.Tx, p Path) (err error) {
+	keys := metaBucket(tx, ttlKeysBucketName)
+	if keys == nil {
+		return nil
+	}
+	k := appendMetaKey(nil, p)
+	t := keys.Get(k)
+	if t == nil {
+		return nil
+	}
+	if index := metaBucket(tx, ttlIndexBucketName); index != nil {
+		if err := index.Delete(append(append([]byte(nil), t...), k...)); err != nil {
+			return fmt.Errorf("bucket %s delete %s: %w", path(MetaBucketName, ttlIndexBucketName), p, err)
+		}
+	}
+	if err := keys.Delete(k); err != nil {
+		return fmt.Errorf("bucket %s delete %s: %w", path(MetaBucketName, ttlKeysBucketName), p, err)
+	}
+	return nil
+}
+
+// Sweep deletes up to limit keys with expiration time that is not after
+// now, and returns the number of deleted keys. If limit is zero or
+// negative, all expired keys are deleted.
+func Sweep(tx *bolt.Tx, now time.Time, limit int) (n int, err error) {
+	index := metaBucket(tx, ttlIndexBucketName)
+	if index == nil {
+		return 0, nil
+	}
+	upper := prefixSuccessor(TimeToBytesUTC(now))
+	var expired [][]byte
+	c := index.Cursor()
+	for k, _ := c.First(); k != nil; k, _ = c.Next() {
+		if upper != nil && bytes.Compare(k, upper) >= 0 {
+			break
+		}
+		if limit > 0 && len(expired) >= limit {
+			break
+		}
+		expired = append(expired, append([]byte(nil), k...))
+	}
+	keys := metaBucket(tx, ttlKeysBucketName)
+	for _, k := range expired {
+		p, err := parseMetaKey(k[TimeBytesLen:])
+		if err != nil {
+			return n, fmt.Errorf("bucket %s key %x: %w", path(MetaBucketName, ttlIndexBucketName), k, err)
+		}
+		if bucket := PathBucket(tx, p); bucket != nil {
+			if err := bucket.Delete(p.Key); err != nil {
+				return n, fmt.Errorf("bucket %s delete %s: %w", path(p.Buckets...), p.Key, err)
+			}
+		}
+		if err := clearVersion(tx, p); err != nil {
+			return n, err
+		}
+		if err := index.Delete(k); err != nil {
+			return n, fmt.Errorf("bucket %s delete %s: %w", path(MetaBucketName, ttlIndexBucketName), p, err)
+		}
+		if keys != nil {
+			if err := keys.Delete(k[TimeBytesLen:]); err != nil {
+				return n, fmt.Errorf("bucket %s delete %s: %w", path(MetaBucketName, ttlKeysBucketName), p, err)
+			}
+		}
+		n++
+	}
+	return n, nil
+}
+
+// JanitorOptions holds optional parameters for the Janitor.
+type JanitorOptions struct {
+	// Interval is the time between two sweeps. Default is one minute.
+	Interval time.Duration
+	// BatchSize is the maximal number of keys deleted in a single
+	// transaction. Default is 1000.
+	BatchSize int
+	// ErrorHandler is called with errors returned by Sweep. If it is
+	// nil, errors are ignored.
+	ErrorHandler func(error)
+}
+
+// Janitor periodically deletes expired keys from the database.
+type Janitor struct {
+	db        *bolt.DB
+	interval  time.Duration
+	batchSize int
+	onError   func(error)
+
+	quit      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewJanitor starts a goroutine that periodically calls Sweep in write
+// transactions on the provided database, deleting expired keys in
+// batches. Close must be called to stop it.
+func NewJanitor(db *bolt.DB, opts *JanitorOptions) (j *Janitor) {
+	if opts == nil {
+		opts = new(JanitorOptions)
+	}
+	j = &Janitor{
+		db:        db,
+		interval:  opts.Interval,
+		batchSize: opts.BatchSize,
+		onError:   opts.ErrorHandler,
+		quit:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	if j.interval <= 0 {
+		j.interval = time.Minute
+	}
+	if j.batchSize <= 0 {
+		j.batchSize = 1000
+	}
+	go j.run()
+	return j
+}
+
+func (j *Janitor) run() {
+	defer close(j.done)
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			j.sweep()
+		case <-j.quit:
+			return
+		}
+	}
+}
+
+// sweep deletes all expired keys in batches, returning early if the
+// janitor is closed.
+func (j *Janitor) sweep() {
+	for {
+		var n int
+		if err := j.db.Update(func(tx *bolt.Tx) (err error) {
+			n, err = Sweep(tx, time.Now(), j.batchSize)
+			return err
+		}); err != nil {
+			if j.onError != nil {
+				j.onError(err)
+			}
+			return
+		}
+		if n < j.batchSize {
+			return
+		}
+		select {
+		case <-j.quit:
+			return
+		default:
+		}
+	}
+}
+
+// Close stops the janitor and waits for the current sweep to finish.
+func (j *Janitor) Close() error {
+	j.closeOnce.Do(func() {
+		close(j.quit)
+	})
+	<-j.done
+	return nil
+}
@@ -0,0 +1,227 @@
+// Copyright (c) 2026, Janoš Guljaš <janos@resenje.org>
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package boltutils
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestTTL(t *testing.T) {
+	db := newTestDB(t)
+	defer db.Destroy()
+
+	bucket1Name := []byte("bucket1")
+	bucket2Name := []byte("bucket2")
+	value := []byte("value")
+	now := time.Now()
+
+	expired := NewKeyPath([]byte("expired"), bucket1Name, bucket2Name)
+	live := NewKeyPath([]byte("live"), bucket1Name, bucket2Name)
+	plain := NewKeyPath([]byte("plain"), bucket1Name)
+
+	if err := db.DB.Update(func(tx *bolt.Tx) error {
+		if _, err := PathPutWithExpiry(tx, false, expired, value, now.Add(-time.Second)); err != nil {
+			return err
+		}
+		if _, err := PathPutWithExpiry(tx, false, live, value, now.Add(time.Hour)); err != nil {
+			return err
+		}
+		_, err := PathPut(tx, false, plain, value)
+		return err
+	}); err != nil {
+		t.Fatalf("bolt db update transaction %s", err)
+	}
+
+	if err := db.DB.View(func(tx *bolt.Tx) error {
+		if v := PathGet(tx, expired); v != nil {
+			t.Errorf("path %s: expected nil, got %s", expired, v)
+		}
+		if v := DeepGet(tx, expired.Elements()...); v != nil {
+			t.Errorf("path %s: expected nil, got %s", expired, v)
+		}
+		if v := PathGet(tx, live); !bytes.Equal(v, value) {
+			t.Errorf("path %s: expected %s, got %s", live, value, v)
+		}
+		if v := PathGet(tx, plain); !bytes.Equal(v, value) {
+			t.Errorf("path %s: expected %s, got %s", plain, value, v)
+		}
+		if e, ok := PathExpiry(tx, live); !ok || !e.Equal(now.Add(time.Hour)) {
+			t.Errorf("path %s: got expiry %s, want %s", live, e, now.Add(time.Hour))
+		}
+		if _, ok := PathExpiry(tx, plain); ok {
+			t.Errorf("path %s: unexpected expiry", plain)
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("bolt db view transaction %s", err)
+	}
+
+	t.Run("PutOverExpired", func(t *testing.T) {
+		if err := db.DB.Update(func(tx *bolt.Tx) error {
+			new, err := PathPutWithExpiry(tx, false, expired, value, now.Add(-time.Second))
+			if err != nil {
+				return err
+			}
+			if !new {
+				t.Error("new is not true")
+			}
+			return nil
+		}); err != nil {
+			t.Fatalf("bolt db update transaction %s", err)
+		}
+	})
+
+	t.Run("Sweep", func(t *testing.T) {
+		var n int
+		if err := db.DB.Update(func(tx *bolt.Tx) (err error) {
+			n, err = Sweep(tx, now, 0)
+			return err
+		}); err != nil {
+			t.Fatalf("bolt db update transaction %s", err)
+		}
+		if n != 1 {
+			t.Errorf("swept %d keys, want %d", n, 1)
+		}
+		if err := db.DB.View(func(tx *bolt.Tx) error {
+			if v := PathBucket(tx, expired).Get(expired.Key); v != nil {
+				t.Errorf("path %s: expected nil, got %s", expired, v)
+			}
+			if _, ok := PathExpiry(tx, expired); ok {
+				t.Errorf("path %s: unexpected expiry", expired)
+			}
+			if v := PathGet(tx, live); !bytes.Equal(v, value) {
+				t.Errorf("path %s: expected %s, got %s", live, value, v)
+			}
+			return nil
+		}); err != nil {
+			t.Fatalf("bolt db view transaction %s", err)
+		}
+	})
+
+	t.Run("SweepLimit", func(t *testing.T) {
+		if err := db.DB.Update(func(tx *bolt.Tx) error {
+			for _, k := range []string{"a", "b", "c"} {
+				if _, err := PathPutWithExpiry(tx, false, NewKeyPath([]byte(k), bucket1Name), value, now.Add(-time.Minute)); err != nil {
+					return err
+				}
+			}
+			n, err := Sweep(tx, now, 2)
+			if err != nil {
+				return err
+			}
+			if n != 2 {
+				t.Errorf("swept %d keys, want %d", n, 2)
+			}
+			n, err = Sweep(tx, now, 2)
+			if err != nil {
+				return err
+			}
+			if n != 1 {
+				t.Errorf("swept %d keys, want %d", n, 1)
+			}
+			return nil
+		}); err != nil {
+			t.Fatalf("bolt db update transaction %s", err)
+		}
+	})
+
+	t.Run("ClearExpiry", func(t *testing.T) {
+		if err := db.DB.Update(func(tx *bolt.Tx) error {
+			if _, err := PathPut(tx, true, live, value); err != nil {
+				return err
+			}
+			if _, ok := PathExpiry(tx, live); ok {
+				t.Errorf("path %s: unexpected expiry", live)
+			}
+			n, err := Sweep(tx, now.Add(2*time.Hour), 0)
+			if err != nil {
+				return err
+			}
+			if n != 0 {
+				t.Errorf("swept %d keys, want %d", n, 0)
+			}
+			return nil
+		}); err != nil {
+			t.Fatalf("bolt db update transaction %s", err)
+		}
+	})
+
+	t.Run("DeleteBucket", func(t *testing.T) {
+		nested := NewKeyPath([]byte("nested"), bucket1Name, bucket2Name, []byte("bucket3"))
+		other := NewKeyPath([]byte("other"), bucket1Name)
+		if err := db.DB.Update(func(tx *bolt.Tx) error {
+			if _, err := PathPutWithExpiry(tx, true, nested, value, now.Add(time.Hour)); err != nil {
+				return err
+			}
+			if _, err := DeepPutVersioned(tx, nested.Bucket().WithKey([]byte("versioned")), value, 0); err != nil {
+				return err
+			}
+			if _, err := PathPutWithExpiry(tx, true, other, value, now.Add(time.Hour)); err != nil {
+				return err
+			}
+			return PathDeleteBucket(tx, true, NewPath(bucket1Name, bucket2Name))
+		}); err != nil {
+			t.Fatalf("bolt db update transaction %s", err)
+		}
+		if err := db.DB.View(func(tx *bolt.Tx) error {
+			for _, name := range [][]byte{ttlKeysBucketName, versionsBucketName} {
+				entries, err := bucketMetaEntries(tx, name, NewPath(bucket1Name, bucket2Name))
+				if err != nil {
+					return err
+				}
+				if len(entries) != 0 {
+					t.Errorf("bucket %s: got %d entries", name, len(entries))
+				}
+			}
+			if n := metaBucket(tx, ttlIndexBucketName).Stats().KeyN; n != 1 {
+				t.Errorf("got %d ttl index entries, want %d", n, 1)
+			}
+			if _, ok := PathExpiry(tx, other); !ok {
+				t.Errorf("path %s: expiry removed", other)
+			}
+			return nil
+		}); err != nil {
+			t.Fatalf("bolt db view transaction %s", err)
+		}
+	})
+
+	t.Run("Janitor", func(t *testing.T) {
+		if err := db.DB.Update(func(tx *bolt.Tx) error {
+			_, err := DeepPutWithTTL(tx, false, time.Millisecond, bucket1Name, []byte("janitor"), value)
+			return err
+		}); err != nil {
+			t.Fatalf("bolt db update transaction %s", err)
+		}
+
+		j := NewJanitor(db.DB, &JanitorOptions{
+			Interval:     5 * time.Millisecond,
+			ErrorHandler: func(err error) { t.Error(err) },
+		})
+		defer j.Close()
+
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			var found bool
+			if err := db.DB.View(func(tx *bolt.Tx) error {
+				found = tx.Bucket(bucket1Name).Get([]byte("janitor")) != nil
+				return nil
+			}); err != nil {
+				t.Fatalf("bolt db view transaction %s", err)
+			}
+			if !found {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatal("expired key not deleted by janitor")
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	})
+}